@@ -6,6 +6,7 @@ import (
 	"minik8s/pkg/controller/autoscaler"
 	"minik8s/pkg/controller/deployment"
 	"minik8s/pkg/controller/jobcontroller"
+	"minik8s/pkg/controller/nodelifecycle"
 	"minik8s/pkg/controller/replicaset"
 	"minik8s/pkg/klog"
 	"minik8s/pkg/service"
@@ -44,3 +45,10 @@ func startServiceController(ctx context.Context, controllerCtx util.ControllerCo
 	go serviceController.Run(ctx)
 	return nil
 }
+
+func startNodeLifecycleController(ctx context.Context, controllerCtx util.ControllerContext) error {
+	klog.Debugf("start running node lifecycle controller\n")
+	nodeLifecycleController := nodelifecycle.NewNodeLifecycleController(controllerCtx)
+	go nodeLifecycleController.Run(ctx)
+	return nil
+}
@@ -8,6 +8,7 @@ type Config struct {
 	*controllers.ReplicaSetControllerOptions
 	*controllers.DeploymentControllerOptions
 	*controllers.AutoscalerControllerOptions
+	*controllers.NodeLifecycleControllerOptions
 }
 
 type CompletedConfig struct {
@@ -12,9 +12,10 @@ type ControllerOptions interface {
 
 type KubeControllerManagerOptions struct {
 	// TODO : add more controllers here
-	ReplicaSetController *controllers.ReplicaSetControllerOptions
-	DeploymentController *controllers.DeploymentControllerOptions
-	AutoscalerController *controllers.AutoscalerControllerOptions
+	ReplicaSetController    *controllers.ReplicaSetControllerOptions
+	DeploymentController    *controllers.DeploymentControllerOptions
+	AutoscalerController    *controllers.AutoscalerControllerOptions
+	NodeLifecycleController *controllers.NodeLifecycleControllerOptions
 }
 
 func NewKubeControllerManagerOptions() *KubeControllerManagerOptions {
@@ -22,6 +23,7 @@ func NewKubeControllerManagerOptions() *KubeControllerManagerOptions {
 		&controllers.ReplicaSetControllerOptions{},
 		&controllers.DeploymentControllerOptions{},
 		&controllers.AutoscalerControllerOptions{},
+		&controllers.NodeLifecycleControllerOptions{},
 	}
 	controllerManagerOptions.SetDefault()
 	return &controllerManagerOptions
@@ -33,6 +35,7 @@ func (opts *KubeControllerManagerOptions) Flags() *pflag.FlagSet {
 	addFlags(opts.ReplicaSetController, &flagSet)
 	addFlags(opts.DeploymentController, &flagSet)
 	addFlags(opts.AutoscalerController, &flagSet)
+	addFlags(opts.NodeLifecycleController, &flagSet)
 	return &flagSet
 }
 
@@ -41,14 +44,16 @@ func (opts *KubeControllerManagerOptions) SetDefault() {
 	setDefault(opts.ReplicaSetController)
 	setDefault(opts.DeploymentController)
 	setDefault(opts.AutoscalerController)
+	setDefault(opts.NodeLifecycleController)
 }
 
 func (opts *KubeControllerManagerOptions) Config() *Config {
 	// TODO : finish this function
 	return &Config{
-		ReplicaSetControllerOptions: opts.ReplicaSetController,
-		DeploymentControllerOptions: opts.DeploymentController,
-		AutoscalerControllerOptions: opts.AutoscalerController,
+		ReplicaSetControllerOptions:    opts.ReplicaSetController,
+		DeploymentControllerOptions:    opts.DeploymentController,
+		AutoscalerControllerOptions:    opts.AutoscalerController,
+		NodeLifecycleControllerOptions: opts.NodeLifecycleController,
 	}
 }
 
@@ -5,8 +5,10 @@ import (
 	"github.com/spf13/cobra"
 	"minik8s/cmd/kube-controller-manager/app/config"
 	"minik8s/cmd/kube-controller-manager/util"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/klog"
 	"minik8s/pkg/listerwatcher"
+	"time"
 )
 
 type InitFunc func(ctx context.Context, controllerCtx util.ControllerContext) (err error)
@@ -33,9 +35,12 @@ func Run(c *config.CompletedConfig) error {
 	if err != nil {
 		return err
 	}
-	if err := StartControllers(context.TODO(), controllerContext, NewControllerInitializers()); err != nil {
+	ctx := context.TODO()
+	if err := StartControllers(ctx, controllerContext, NewControllerInitializers()); err != nil {
 		klog.Fatalf("error starting controllers: %v\n", err)
 	}
+	// the controllers have asked for their informers while starting
+	controllerContext.InformerFactory.Start(ctx.Done())
 	// TODO: give each controller a new unique ls
 	select {}
 }
@@ -47,11 +52,12 @@ func CreateControllerContext(c *config.CompletedConfig) (*util.ControllerContext
 		return nil, err
 	}
 	controllerContext := &util.ControllerContext{
-		Ls:             ls,
-		MasterIP:       "127.0.0.1",
-		HttpServerPort: "8080",
-		PromServerPort: "9090",
-		Config:         c,
+		Ls:              ls,
+		InformerFactory: informer.NewSharedInformerFactory(ls, time.Duration(c.ResyncIntervals)*time.Second),
+		MasterIP:        "127.0.0.1",
+		HttpServerPort:  "8080",
+		PromServerPort:  "9090",
+		Config:          c,
 	}
 	return controllerContext, nil
 }
@@ -64,6 +70,7 @@ func NewControllerInitializers() map[string]InitFunc {
 	controller["autoscaler"] = startAutoscalerController
 	controller["job"] = startJobController
 	controller["service"] = startServiceController
+	controller["nodelifecycle"] = startNodeLifecycleController
 	return controller
 }
 
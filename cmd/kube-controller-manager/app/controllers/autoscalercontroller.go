@@ -0,0 +1,19 @@
+package controllers
+
+import "github.com/spf13/pflag"
+
+type AutoscalerControllerOptions struct {
+	MetricsIntervals int
+}
+
+func (o *AutoscalerControllerOptions) AddFlags(fs *pflag.FlagSet) {
+	if o == nil {
+		return
+	}
+	fs.IntVar(&o.MetricsIntervals, "metrics-interval", o.MetricsIntervals,
+		"Interval in seconds of autoscaler controller's metrics collection.")
+}
+
+func (o *AutoscalerControllerOptions) SetDefault() {
+	o.MetricsIntervals = 15
+}
@@ -11,7 +11,7 @@ func (o *DeploymentControllerOptions) AddFlags(fs *pflag.FlagSet) {
 		return
 	}
 	fs.IntVar(&o.ResyncIntervals, "metadata-resync", o.ResyncIntervals,
-		"Interval in seconds of deployment controller's re-synchronization.")
+		"Interval in seconds of the shared informers' re-synchronization.")
 }
 
 func (o *DeploymentControllerOptions) SetDefault() {
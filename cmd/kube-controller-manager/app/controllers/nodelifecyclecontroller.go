@@ -0,0 +1,23 @@
+package controllers
+
+import "github.com/spf13/pflag"
+
+type NodeLifecycleControllerOptions struct {
+	NodeMonitorPeriod      int
+	NodeMonitorGracePeriod int
+}
+
+func (o *NodeLifecycleControllerOptions) AddFlags(fs *pflag.FlagSet) {
+	if o == nil {
+		return
+	}
+	fs.IntVar(&o.NodeMonitorPeriod, "node-monitor-period", o.NodeMonitorPeriod,
+		"Interval in seconds of node lifecycle controller's checks of the node heartbeats.")
+	fs.IntVar(&o.NodeMonitorGracePeriod, "node-monitor-grace-period", o.NodeMonitorGracePeriod,
+		"Seconds a node may stop posting heartbeats before it is marked NotReady and its pods are evicted.")
+}
+
+func (o *NodeLifecycleControllerOptions) SetDefault() {
+	o.NodeMonitorPeriod = 5
+	o.NodeMonitorGracePeriod = 40
+}
@@ -2,13 +2,15 @@ package util
 
 import (
 	"minik8s/cmd/kube-controller-manager/app/config"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/listerwatcher"
 )
 
 type ControllerContext struct {
-	Ls             *listerwatcher.ListerWatcher
-	MasterIP       string
-	HttpServerPort string
-	PromServerPort string
-	Config         *config.CompletedConfig
+	Ls              *listerwatcher.ListerWatcher
+	InformerFactory *informer.SharedInformerFactory // InformerFactory is shared by every controller, it is started after them
+	MasterIP        string
+	HttpServerPort  string
+	PromServerPort  string
+	Config          *config.CompletedConfig
 }
@@ -2,14 +2,20 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"minik8s/pkg/client"
 	"minik8s/pkg/listerwatcher"
 	"minik8s/pkg/scheduler"
+	"minik8s/pkg/scheduler/framework"
 )
 
-func SchedulerRun(selectType string) {
+func SchedulerRun(cfg *framework.Config) {
 	clientConfig := client.Config{Host: "127.0.0.1:8080"}
-	sched := scheduler.NewScheduler(listerwatcher.DefaultConfig(), clientConfig, selectType)
+	sched, err := scheduler.NewScheduler(listerwatcher.DefaultConfig(), clientConfig, cfg, nil)
+	if err != nil {
+		fmt.Printf("[Scheduler] start fail: %s\n", err.Error())
+		return
+	}
 	sched.Run(context.TODO())
 	select {}
 }
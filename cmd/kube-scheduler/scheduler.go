@@ -4,12 +4,24 @@ import (
 	"fmt"
 	"minik8s/cmd/kube-scheduler/app"
 	"minik8s/pkg/scheduler"
+	"minik8s/pkg/scheduler/framework"
 	"os"
+	"path/filepath"
 )
 
+// the argument is a policy, or the path of a yaml file configuring the plugins
 func main() {
-	selectType := scheduler.SelectRandom
+	selectType := scheduler.SelectLeastAllocated
 	if len(os.Args) > 1 {
+		if ext := filepath.Ext(os.Args[1]); ext == ".yaml" || ext == ".yml" {
+			cfg, err := framework.LoadConfig(os.Args[1])
+			if err != nil {
+				fmt.Printf("read scheduler config %s fail: %s\n", os.Args[1], err.Error())
+				return
+			}
+			app.SchedulerRun(cfg)
+			return
+		}
 		selectType = os.Args[1]
 		switch selectType {
 		case "random":
@@ -24,12 +36,16 @@ func main() {
 			selectType = scheduler.SelectAffinity
 		case scheduler.SelectAffinity:
 			selectType = scheduler.SelectAffinity
+		case "least-allocated":
+			selectType = scheduler.SelectLeastAllocated
+		case scheduler.SelectLeastAllocated:
+			selectType = scheduler.SelectLeastAllocated
 		default:
-			fmt.Printf("unknown type:%s, use default schedule police: random", selectType)
-			selectType = scheduler.SelectRandom
+			fmt.Printf("unknown type:%s, use default schedule police: least-allocated", selectType)
+			selectType = scheduler.SelectLeastAllocated
 		}
 	} else {
-		fmt.Println("use default schedule police: random")
+		fmt.Println("use default schedule police: least-allocated")
 	}
-	app.SchedulerRun(selectType)
+	app.SchedulerRun(scheduler.DefaultConfig(selectType))
 }
@@ -12,7 +12,6 @@ import (
 	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
 	"os"
-	path2 "path"
 	"strings"
 )
 
@@ -25,6 +24,7 @@ const (
 	Pod                     string = "Pod"
 	Service                 string = "Service"
 	DnsAndTrans             string = "DnsAndTrans"
+	Namespace               string = "Namespace"
 )
 
 var (
@@ -105,6 +105,11 @@ func analyzeFile(path string) {
 			return
 		}
 		break
+	case Namespace:
+		if err := CaseNamespace(file, path, unmarshal); err != nil {
+			return
+		}
+		break
 	case "":
 		fmt.Printf("kind field is unspecified\n")
 		return
@@ -124,7 +129,8 @@ func CaseDeployment(file []byte, path string, unmarshal func([]byte, any) error)
 	}
 	deployment.Complete()
 	fmt.Printf("%+v\n", deployment)
-	err = client.Put(baseUrl+"/registry/deployment/default/"+deployment.Metadata.Name, deployment)
+	ns := objectNamespace(&deployment.Metadata)
+	err = client.Put(baseUrl+config.NamespacedKey(config.DeploymentPrefix, ns, deployment.Metadata.Name), deployment)
 	if err != nil {
 		fmt.Printf("Error applying `file %s`.\n%s\n", path, err.Error())
 		return err
@@ -139,7 +145,8 @@ func CaseReplicaset(file []byte, path string, unmarshal func([]byte, any) error)
 		fmt.Printf("Error unmarshaling file %s\n", path)
 		return err
 	}
-	err = client.Put(baseUrl+path2.Join(config.RSConfigPrefix, replicaset.ObjectMeta.Name), replicaset)
+	ns := objectNamespace(&replicaset.ObjectMeta)
+	err = client.Put(baseUrl+config.NamespacedKey(config.RSConfigPrefix, ns, replicaset.ObjectMeta.Name), replicaset)
 	if err != nil {
 		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
 		return err
@@ -154,7 +161,8 @@ func CaseHPA(file []byte, path string, unmarshal func([]byte, any) error) error
 		fmt.Printf("Error unmarshaling file %s\n", path)
 		return err
 	}
-	err = client.Put(baseUrl+"/registry/autoscaler/default/"+hpa.Metadata.Name, hpa)
+	ns := objectNamespace(&hpa.Metadata)
+	err = client.Put(baseUrl+config.NamespacedKey(config.AutoscalerPrefix, ns, hpa.Metadata.Name), hpa)
 	if err != nil {
 		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
 		return err
@@ -169,7 +177,8 @@ func CasePod(file []byte, path string, unmarshal func([]byte, any) error) error
 		return err
 	}
 	fmt.Printf("%+v\n", pod)
-	err = client.Put(baseUrl+"/registry/podConfig/default/"+pod.Name, pod)
+	ns := objectNamespace(&pod.ObjectMeta)
+	err = client.Put(baseUrl+config.NamespacedKey(config.PodConfigPREFIX, ns, pod.Name), pod)
 	if err != nil {
 		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
 		return err
@@ -183,7 +192,8 @@ func CaseService(file []byte, path string, unmarshal func([]byte, any) error) er
 		fmt.Printf("Error unmarshaling file %s\n", path)
 		return err
 	}
-	err = client.Put(baseUrl+"/registry/serviceConfig/default/"+service.MetaData.Name, service)
+	ns := objectNamespace(&service.MetaData)
+	err = client.Put(baseUrl+config.NamespacedKey(config.ServiceConfigPrefix, ns, service.MetaData.Name), service)
 	if err != nil {
 		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
 		return err
@@ -197,7 +207,8 @@ func CaseDnsAndTrans(file []byte, path string, unmarshal func([]byte, any) error
 		fmt.Printf("Error unmarshaling file %s\n", path)
 		return err
 	}
-	err = client.Put(baseUrl+"/registry/dnsAndTrans/default/"+dnsAndTrans.MetaData.Name, dnsAndTrans)
+	ns := objectNamespace(&dnsAndTrans.MetaData)
+	err = client.Put(baseUrl+config.NamespacedKey(config.DnsAndTransPrefix, ns, dnsAndTrans.MetaData.Name), dnsAndTrans)
 	if err != nil {
 		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
 		return err
@@ -230,7 +241,23 @@ func CaseGpuJob(file []byte, path string, unmarshal func([]byte, any) error) err
 		fmt.Printf("Error uploading file `%s`\n.%s\n", gpuJob.Spec.ZipPath, err.Error())
 		return err
 	}
-	err = client.Put(baseUrl+"/registry/job/default/"+jobKey, gpuJob)
+	ns := objectNamespace(&gpuJob.Metadata)
+	err = client.Put(baseUrl+config.NamespacedKey(config.JobPrefix, ns, jobKey), gpuJob)
+	if err != nil {
+		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
+		return err
+	}
+	return nil
+}
+
+func CaseNamespace(file []byte, path string, unmarshal func([]byte, any) error) error {
+	ns := object.Namespace{}
+	err := unmarshal(file, &ns)
+	if err != nil {
+		fmt.Printf("Error unmarshaling file %s\n", path)
+		return err
+	}
+	err = client.Put(baseUrl+config.NAMESPACE_PREFIX+"/"+ns.MetaData.Name, ns)
 	if err != nil {
 		fmt.Printf("Error applying file `file%s`\n.%s\n", path, err.Error())
 		return err
@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
 )
 
@@ -33,7 +34,7 @@ func deleteResource(cmd *cobra.Command, args []string) {
 	}
 	switch resource {
 	case "replicaset":
-		url := baseUrl + fmt.Sprintf("/registry/rsConfig/default/%s", resourceName)
+		url := resourceURL(config.RSConfigPrefix, resourceName)
 		err := client.Del(url)
 		if err != nil {
 			fmt.Println(err.Error())
@@ -41,7 +42,7 @@ func deleteResource(cmd *cobra.Command, args []string) {
 		}
 		break
 	case "pod":
-		url := baseUrl + fmt.Sprintf("/registry/podConfig/default/%s", resourceName)
+		url := resourceURL(config.PodConfigPREFIX, resourceName)
 		err := client.Del(url)
 		if err != nil {
 			fmt.Println(err.Error())
@@ -49,7 +50,7 @@ func deleteResource(cmd *cobra.Command, args []string) {
 		}
 		break
 	case "service":
-		url := baseUrl + fmt.Sprintf("/registry/serviceConfig/default/%s", resourceName)
+		url := resourceURL(config.ServiceConfigPrefix, resourceName)
 		err := client.Del(url)
 		if err != nil {
 			fmt.Println(err.Error())
@@ -57,7 +58,7 @@ func deleteResource(cmd *cobra.Command, args []string) {
 		}
 		break
 	case "dns":
-		url := baseUrl + fmt.Sprintf("/registry/dnsAndTrans/default/%s", resourceName)
+		url := resourceURL(config.DnsAndTransPrefix, resourceName)
 		err := client.Del(url)
 		if err != nil {
 			fmt.Println(err.Error())
@@ -65,7 +66,7 @@ func deleteResource(cmd *cobra.Command, args []string) {
 		}
 		break
 	case "deployment":
-		url := baseUrl + fmt.Sprintf("/registry/%s/default/%s", resource, resourceName)
+		url := resourceURL(config.DeploymentPrefix, resourceName)
 		err := client.Del(url)
 		if err != nil {
 			fmt.Println(err.Error())
@@ -73,7 +74,16 @@ func deleteResource(cmd *cobra.Command, args []string) {
 		}
 		break
 	case "autoscaler":
-		url := baseUrl + fmt.Sprintf("/registry/%s/default/%s", resource, resourceName)
+		url := resourceURL(config.AutoscalerPrefix, resourceName)
+		err := client.Del(url)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		break
+	case "namespace":
+		// refused by api server if the namespace is not empty
+		url := baseUrl + config.NAMESPACE_PREFIX + "/" + resourceName
 		err := client.Del(url)
 		if err != nil {
 			fmt.Println(err.Error())
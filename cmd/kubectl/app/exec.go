@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/websocket"
+	"io"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"minik8s/pkg/kubelet/stream"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+var (
+	execContainer string
+	execStdin     bool
+	execTty       bool
+
+	cmdExec = &cobra.Command{
+		Use:   "exec <pod-name> [-c container] [-i] [-t] -- command [args...]",
+		Short: "run a command in a container of a pod",
+		Long: "run a command in a container of a pod, the container may be left out if the pod has only one.\n" +
+			"-i passes stdin to the command, -t runs it in a terminal, e.g. exec -it nginx -- sh",
+		Args: cobra.MinimumNArgs(2),
+		Run:  execCommand,
+	}
+)
+
+func init() {
+	cmdExec.Flags().StringVarP(&execContainer, "container", "c", "", "name of the container")
+	cmdExec.Flags().BoolVarP(&execStdin, "stdin", "i", false, "pass stdin to the command")
+	cmdExec.Flags().BoolVarP(&execTty, "tty", "t", false, "run the command in a terminal")
+	rootCmd.AddCommand(cmdExec)
+}
+
+// podWebsocketURL returns the websocket url of the pod under route with query
+func podWebsocketURL(route string, podName string, query url.Values) string {
+	path := strings.Replace(route, ":"+config.ParamNamespace, namespace, 1)
+	path = strings.Replace(path, ":"+config.ParamResourceName, podName, 1)
+	wsUrl := strings.Replace(baseUrl, "http", "ws", 1)
+	return wsUrl + path + "?" + query.Encode()
+}
+
+func execCommand(cmd *cobra.Command, args []string) {
+	if cmd.ArgsLenAtDash() != 1 {
+		fmt.Println("Usage: " + cmd.Use)
+		return
+	}
+	query := url.Values{}
+	if execContainer != "" {
+		query.Set("container", execContainer)
+	}
+	for _, arg := range args[1:] {
+		query.Add("command", arg)
+	}
+	if execStdin {
+		query.Set("stdin", "true")
+	}
+	if execTty {
+		query.Set("tty", "true")
+	}
+	ws, err := websocket.Dial(podWebsocketURL(config.PodExec, args[0], query), "", baseUrl)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	defer ws.Close()
+
+	var stdin io.Reader
+	if execStdin {
+		stdin = os.Stdin
+	}
+	var resize chan containerRuntime.TerminalSize
+	restore := func() {}
+	if fd, isTerminal := term.GetFdInfo(os.Stdin); execTty && isTerminal {
+		state, err := term.SetRawTerminal(fd)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		restore = func() { _ = term.RestoreTerminal(fd, state) }
+		resize = make(chan containerRuntime.TerminalSize, 1)
+		sendSize := func() {
+			if size, err := term.GetWinsize(fd); err == nil {
+				select {
+				case resize <- containerRuntime.TerminalSize{Width: size.Width, Height: size.Height}:
+				default:
+				}
+			}
+		}
+		sendSize()
+		winch := make(chan os.Signal, 1)
+		signal.Notify(winch, syscall.SIGWINCH)
+		go func() {
+			for range winch {
+				sendSize()
+			}
+		}()
+	}
+	status, err := stream.Exec(ws, stdin, os.Stdout, os.Stderr, resize)
+	restore()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if status.Message != "" {
+		fmt.Println("Error from server: " + status.Message)
+		os.Exit(1)
+	}
+	if status.ExitCode != 0 {
+		fmt.Printf("command terminated with exit code %d\n", status.ExitCode)
+		os.Exit(status.ExitCode)
+	}
+}
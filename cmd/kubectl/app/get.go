@@ -32,7 +32,9 @@ type beautifiedPod struct {
 	Ctime    string
 	PodIp    string
 	NodeName string
+	Ready    string
 	Status   string
+	Restarts int32
 }
 
 type beautifiedAutoscaler struct {
@@ -60,7 +62,7 @@ func (b *beautifiedAutoscaler) ToString() string {
 }
 
 func (bPod *beautifiedPod) ToString() string {
-	result := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n", bPod.Name, bPod.Ctime, bPod.PodIp, bPod.NodeName, bPod.Status)
+	result := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%d\n", bPod.Name, bPod.Ctime, bPod.PodIp, bPod.NodeName, bPod.Ready, bPod.Status, bPod.Restarts)
 	return result
 }
 
@@ -74,10 +76,12 @@ type beautifiedNode struct {
 	MasterIp      string
 	NodeIp        string
 	NodeIpAndMask string
+	Status        string
+	Taints        string
 }
 
 func (bNode *beautifiedNode) ToString() string {
-	result := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\n", bNode.Name, bNode.Ctime, bNode.MasterIp, bNode.NodeIp, bNode.NodeIpAndMask)
+	result := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s\n", bNode.Name, bNode.Ctime, bNode.MasterIp, bNode.NodeIp, bNode.NodeIpAndMask, bNode.Status, bNode.Taints)
 	return result
 }
 
@@ -134,10 +138,10 @@ func BDPHeader() string {
 }
 
 func NODEHeader() string {
-	return "NodeName\tCtime\tMasterIp\tNodeIp\tNodeIpAndMask\n"
+	return "NodeName\tCtime\tMasterIp\tNodeIp\tNodeIpAndMask\tStatus\tTaints\n"
 }
 func PODHeader() string {
-	return "PodName\tCtime\tPodIp\tNodeName\tStatus\n"
+	return "PodName\tCtime\tPodIp\tNodeName\tReady\tStatus\tRestarts\n"
 }
 func SERVICEHeader() string {
 	return "ServiceName\tCtime\tClusterIp\tPorts\tStatus\n"
@@ -171,10 +175,21 @@ var (
 	}
 )
 
+var allNamespaces bool
+
 func init() {
+	cmdGet.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "list the resources across all namespaces")
 	rootCmd.AddCommand(cmdGet)
 }
 
+// listURL is the same as resourceURL except that it lists every namespace when -A is given
+func listURL(root string, name string) string {
+	if allNamespaces && name == "" {
+		return baseUrl + root
+	}
+	return resourceURL(root, name)
+}
+
 func getHandler(cmd *cobra.Command, args []string) {
 	var name string
 	if len(args) == 2 {
@@ -206,12 +221,15 @@ func getHandler(cmd *cobra.Command, args []string) {
 	case "job":
 		caseJob(name)
 		return
+	case "namespace":
+		caseNamespace(name)
+		return
 	default:
 		fmt.Println("Unknown resource ", args[0])
 	}
 }
 func caseDnsAndTrans(name string) {
-	url := baseUrl + path.Join(config.DnsAndTransPrefix, name)
+	url := listURL(config.DnsAndTransPrefix, name)
 	listRes, err := client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -263,6 +281,21 @@ func caseNode(name string) {
 			MasterIp:      node.MasterIp,
 			NodeIp:        node.Spec.DynamicIp,
 			NodeIpAndMask: node.Spec.NodeIpAndMask,
+			Status:        "Unknown",
+			Taints:        "<none>",
+		}
+		if condition := node.GetCondition(object.NodeReady); condition != nil && condition.Status != object.ConditionUnknown {
+			bNode.Status = "NotReady"
+			if condition.Status == object.ConditionTrue {
+				bNode.Status = "Ready"
+			}
+		}
+		if len(node.Spec.Taints) != 0 {
+			var taints []string
+			for i := range node.Spec.Taints {
+				taints = append(taints, node.Spec.Taints[i].ToString())
+			}
+			bNode.Taints = strings.Join(taints, ",")
 		}
 		results = append(results, bNode)
 	}
@@ -273,7 +306,7 @@ func caseNode(name string) {
 }
 
 func casePod(name string) {
-	url := baseUrl + path.Join(config.PodRuntimePrefix, name)
+	url := listURL(config.PodRuntimePrefix, name)
 	listRes, err := client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -291,8 +324,16 @@ func casePod(name string) {
 			Ctime:    pod.Ctime,
 			PodIp:    pod.Status.PodIP,
 			NodeName: pod.Spec.NodeName,
-			Status:   pod.Status.Phase,
+			Status:   podStatus(pod),
+		}
+		ready := 0
+		for _, container := range pod.Status.ContainerStatuses {
+			if container.Ready {
+				ready++
+			}
+			bPod.Restarts += container.RestartCount
 		}
+		bPod.Ready = fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
 		results = append(results, bPod)
 	}
 	fmt.Print(PODHeader())
@@ -300,8 +341,26 @@ func casePod(name string) {
 		fmt.Print(bPod.ToString())
 	}
 }
+
+// podStatus tells why the pod is not running well, e.g. CrashLoopBackOff, or its phase
+func podStatus(pod *object.Pod) string {
+	status := pod.Status.Phase
+	if pod.Status.Reason != "" {
+		status = pod.Status.Reason
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		if container.State.Waiting != nil && container.State.Waiting.Reason != "" {
+			return container.State.Waiting.Reason
+		}
+		if container.State.Terminated != nil && container.State.Terminated.Reason != "" && pod.Status.Phase != object.Running {
+			status = container.State.Terminated.Reason
+		}
+	}
+	return status
+}
+
 func caseService(name string) {
-	url := baseUrl + path.Join(config.ServicePrefix, name)
+	url := listURL(config.ServicePrefix, name)
 	listRes, err := client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -338,7 +397,7 @@ func caseService(name string) {
 
 func caseDeployment(name string) {
 
-	url := baseUrl + config.RSConfigPrefix
+	url := listURL(config.RSConfigPrefix, "")
 	listRes, err := client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -357,17 +416,18 @@ func caseDeployment(name string) {
 		}
 		for _, ownerRef := range rs.OwnerReferences {
 			if ownerRef.Kind == "Deployment" && ownerRef.Name != "" {
-				rsOld, ok := dm2rs[ownerRef.Name]
+				owner := config.NamespacedName(rs.Namespace, ownerRef.Name)
+				rsOld, ok := dm2rs[owner]
 				if !ok {
-					dm2rs[ownerRef.Name] = rsTmp
+					dm2rs[owner] = rsTmp
 				} else if rsOld.createVersion < rsTmp.createVersion {
-					dm2rs[ownerRef.Name] = rsTmp
+					dm2rs[owner] = rsTmp
 				}
 			}
 		}
 	}
 
-	url = baseUrl + path.Join("/registry/deployment/default", name)
+	url = listURL(config.DeploymentPrefix, name)
 	listRes, err = client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -380,10 +440,10 @@ func caseDeployment(name string) {
 		if err != nil {
 			continue
 		}
-		rs, ok := dm2rs[deployment.Metadata.Name]
+		rs, ok := dm2rs[config.NamespacedName(deployment.Metadata.Namespace, deployment.Metadata.Name)]
 		if ok {
 			status := rsStatus{}
-			data, err := client.GetWithParams(baseUrl+config.RS_POD, map[string]string{"rsName": rs.replicaset.Name, "uid": rs.replicaset.UID})
+			data, err := client.GetWithParams(baseUrl+config.RS_POD, map[string]string{"rsName": rs.replicaset.Name, "uid": rs.replicaset.UID, "namespace": rs.replicaset.Namespace})
 			if err != nil {
 				continue
 			}
@@ -409,7 +469,7 @@ func caseDeployment(name string) {
 }
 
 func caseReplicaset(name string) {
-	url := baseUrl + path.Join(config.RSConfigPrefix, name)
+	url := listURL(config.RSConfigPrefix, name)
 	listRes, err := client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -428,7 +488,7 @@ func caseReplicaset(name string) {
 			podName: rs.Spec.Template.Name,
 		}
 		status := rsStatus{}
-		data, err := client.GetWithParams(baseUrl+config.RS_POD, map[string]string{"rsName": rs.Name, "uid": rs.UID})
+		data, err := client.GetWithParams(baseUrl+config.RS_POD, map[string]string{"rsName": rs.Name, "uid": rs.UID, "namespace": rs.Namespace})
 		if err != nil {
 			continue
 		}
@@ -446,7 +506,7 @@ func caseReplicaset(name string) {
 }
 
 func caseAutoscaler(name string) {
-	url := baseUrl + path.Join("/registry/autoscaler/default", name)
+	url := listURL(config.AutoscalerPrefix, name)
 	listRes, err := client.Get(url)
 	if err != nil {
 		fmt.Println(err.Error())
@@ -513,7 +573,7 @@ func caseJob(name string) {
 		}
 		fmt.Printf("%-15s%-50s\n%-15s%-50s\n", "Job", path.Base(listRes[0].Key), "CommittedBy", tmp.PodName)
 
-		listRes, err = client.Get(baseUrl + path.Join(config.PodRuntimePrefix, tmp.PodName))
+		listRes, err = client.Get(baseUrl + config.NamespacedKey(config.PodRuntimePrefix, tmp.Namespace, tmp.PodName))
 		if err != nil {
 			fmt.Println(err.Error())
 			return
@@ -533,3 +593,21 @@ func caseJob(name string) {
 		fmt.Println(string(data))
 	}
 }
+
+func caseNamespace(name string) {
+	url := baseUrl + path.Join(config.NAMESPACE_PREFIX, name)
+	listRes, err := client.Get(url)
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("%-30s\t%-20s\t%-10s\n", "NamespaceName", "Ctime", "Status")
+	for _, res := range listRes {
+		ns := object.Namespace{}
+		err = json.Unmarshal(res.ValueBytes, &ns)
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%-30s\t%-20s\t%-10s\n", ns.MetaData.Name, ns.MetaData.Ctime, ns.Status.Phase)
+	}
+}
@@ -5,13 +5,16 @@ import (
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
 	"os"
 )
 
 var (
 	// Used for flags.
-	cfgFile string
-	baseUrl string
+	cfgFile   string
+	baseUrl   string
+	namespace string
 
 	rootCmd = &cobra.Command{
 		Use:   "odin",
@@ -28,6 +31,21 @@ func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "podConfig", "", "podConfig file (default is $HOME/.odin.yaml)")
 	rootCmd.PersistentFlags().Bool("viper", true, "use Viper for configuration")
+	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", config.DefaultNamespace, "namespace of the resource")
+}
+
+// resourceURL returns the url of name under root in the namespace given by the command line,
+// the url of the whole namespace is returned if name is empty
+func resourceURL(root string, name string) string {
+	return baseUrl + config.NamespacedKey(root, namespace, name)
+}
+
+// objectNamespace prefers the namespace written in the file to the one given by the command line
+func objectNamespace(meta *object.ObjectMeta) string {
+	if meta.Namespace == "" {
+		meta.Namespace = namespace
+	}
+	return meta.Namespace
 }
 
 func er(msg interface{}) {
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"io"
+	"io/ioutil"
+	"minik8s/pkg/apiserver/config"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	logContainer  string
+	logFollow     bool
+	logTail       int64
+	logPrevious   bool
+	logTimestamps bool
+
+	cmdLogs = &cobra.Command{
+		Use:   "logs <pod-name> [-c container] [-f] [--tail N] [--previous]",
+		Short: "print the logs of a container of a pod",
+		Long: "print the logs of a container of a pod, the container may be left out if the pod has only one.\n" +
+			"--previous prints the logs of the last run of a container which restarted.",
+		Args: cobra.ExactArgs(1),
+		Run:  logs,
+	}
+)
+
+func init() {
+	cmdLogs.Flags().StringVarP(&logContainer, "container", "c", "", "name of the container")
+	cmdLogs.Flags().BoolVarP(&logFollow, "follow", "f", false, "keep printing the logs as they are written")
+	cmdLogs.Flags().Int64Var(&logTail, "tail", -1, "number of lines to print from the end of the logs, -1 is all of them")
+	cmdLogs.Flags().BoolVarP(&logPrevious, "previous", "p", false, "print the logs of the previous run of the container")
+	cmdLogs.Flags().BoolVar(&logTimestamps, "timestamps", false, "print the time of every line")
+	rootCmd.AddCommand(cmdLogs)
+}
+
+func logs(cmd *cobra.Command, args []string) {
+	query := url.Values{}
+	if logContainer != "" {
+		query.Set("container", logContainer)
+	}
+	if logFollow {
+		query.Set("follow", "true")
+	}
+	if logTail >= 0 {
+		query.Set("tailLines", strconv.FormatInt(logTail, 10))
+	}
+	if logPrevious {
+		query.Set("previous", "true")
+	}
+	if logTimestamps {
+		query.Set("timestamps", "true")
+	}
+	path := strings.Replace(config.PodLog, ":"+config.ParamNamespace, namespace, 1)
+	path = strings.Replace(path, ":"+config.ParamResourceName, args[0], 1)
+	resp, err := http.Get(baseUrl + path + "?" + query.Encode())
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(resp.Body)
+		fmt.Printf("Error from server (%s): %s\n", http.StatusText(resp.StatusCode), string(message))
+		return
+	}
+	_, _ = io.Copy(os.Stdout, resp.Body)
+}
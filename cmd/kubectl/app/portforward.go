@@ -0,0 +1,118 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/websocket"
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/client"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	cmdPortForward = &cobra.Command{
+		Use:   "port-forward <pod-name> [local-port:]remote-port ...",
+		Short: "forward local ports to the ports of a pod",
+		Long: "forward local ports to the ports of a pod until interrupted, e.g. port-forward nginx 8080:80.\n" +
+			"The local port is the remote port if it is left out, and a random one if it is empty as in :80.",
+		Args: cobra.MinimumNArgs(2),
+		Run:  portForward,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(cmdPortForward)
+}
+
+// parsePortMapping parses [local:]remote, the local port is 0 if it is empty
+func parsePortMapping(mapping string) (int, int, error) {
+	local, remote := mapping, mapping
+	if index := strings.Index(mapping, ":"); index >= 0 {
+		local, remote = mapping[:index], mapping[index+1:]
+	}
+	remotePort, err := strconv.Atoi(remote)
+	if err != nil || remotePort <= 0 || remotePort > 65535 {
+		return 0, 0, fmt.Errorf("invalid remote port in %s", mapping)
+	}
+	localPort := 0
+	if local != "" {
+		localPort, err = strconv.Atoi(local)
+		if err != nil || localPort < 0 || localPort > 65535 {
+			return 0, 0, fmt.Errorf("invalid local port in %s", mapping)
+		}
+	}
+	return localPort, remotePort, nil
+}
+
+func portForward(cmd *cobra.Command, args []string) {
+	podName := args[0]
+	listRes, err := client.Get(resourceURL(config.PodRuntimePrefix, podName))
+	if err != nil || len(listRes) == 0 {
+		fmt.Printf("pod %s not found\n", podName)
+		return
+	}
+	pod := &object.Pod{}
+	if err = json.Unmarshal(listRes[0].ValueBytes, pod); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	if pod.Status.Phase != object.Running {
+		fmt.Printf("unable to forward port because pod is not running. Current status=%s\n", pod.Status.Phase)
+		return
+	}
+	var listeners []net.Listener
+	var remotePorts []int
+	for _, mapping := range args[1:] {
+		localPort, remotePort, err := parsePortMapping(mapping)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", localPort))
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Printf("Forwarding from %s -> %d\n", listener.Addr().String(), remotePort)
+		listeners = append(listeners, listener)
+		remotePorts = append(remotePorts, remotePort)
+	}
+	done := make(chan struct{})
+	for i := range listeners {
+		go forwardPort(podName, listeners[i], remotePorts[i], done)
+	}
+	<-done
+}
+
+// forwardPort forwards every connection to listener through its own websocket, until listener fails
+func forwardPort(podName string, listener net.Listener, remotePort int, done chan<- struct{}) {
+	defer func() { done <- struct{}{} }()
+	query := url.Values{}
+	query.Set("port", strconv.Itoa(remotePort))
+	wsUrl := podWebsocketURL(config.PodPortForward, podName, query)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Printf("Handling connection for %d\n", remotePort)
+		go func() {
+			ws, err := websocket.Dial(wsUrl, "", baseUrl)
+			if err != nil {
+				fmt.Println(err.Error())
+				conn.Close()
+				return
+			}
+			ws.PayloadType = websocket.BinaryFrame
+			_ = containerRuntime.CopyStreams(context.Background(), conn, ws)
+		}()
+	}
+}
@@ -9,5 +9,5 @@ var commandLineResource set.Set[string]
 
 func init() {
 	//commandLineResources = set.NewSet[string]("pods", "deployments", "replicasets", "svc","service")
-	commandLineResource = set.NewSet[string]("pod", "deployment", "replicaset", "service", "dns", "autoscaler")
+	commandLineResource = set.NewSet[string]("pod", "deployment", "replicaset", "service", "dns", "autoscaler", "namespace")
 }
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/client"
+	"strings"
+	"time"
+)
+
+var (
+	cmdTaint = &cobra.Command{
+		Use:   "taint node <node-name|node-ip> key[=value]:Effect ... | key[:Effect]- ...",
+		Short: "add or remove the taints of a node",
+		Long: "add or remove the taints of a node, Effect is NoSchedule, PreferNoSchedule or NoExecute.\n" +
+			"key[=value]:Effect adds the taint or replaces the value of the taint with the same key and effect,\n" +
+			"key:Effect- removes the taint with the key and effect, key- removes all the taints with the key.",
+		Args: cobra.MinimumNArgs(3),
+		Run:  taintNode,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(cmdTaint)
+}
+
+func taintNode(cmd *cobra.Command, args []string) {
+	if args[0] != "node" {
+		fmt.Println("Only node can be tainted")
+		return
+	}
+	var toAdd, toRemove []object.Taint
+	for _, arg := range args[2:] {
+		taint, remove, err := parseTaint(arg)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		if remove {
+			toRemove = append(toRemove, taint)
+		} else {
+			toAdd = append(toAdd, taint)
+		}
+	}
+	dynamicIp, err := findNodeIp(args[1])
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	restClient := client.RESTClient{Base: baseUrl}
+	_, err = restClient.UpdateNode(dynamicIp, func(node *object.Node) error {
+		taints := node.Spec.Taints
+		for _, r := range toRemove {
+			var kept []object.Taint
+			for _, t := range taints {
+				if t.Key != r.Key || (r.Effect != "" && t.Effect != r.Effect) {
+					kept = append(kept, t)
+				}
+			}
+			if len(kept) == len(taints) {
+				return fmt.Errorf("taint %q not found", r.Key)
+			}
+			taints = kept
+		}
+		for _, a := range toAdd {
+			replaced := false
+			for i := range taints {
+				if taints[i].Key == a.Key && taints[i].Effect == a.Effect {
+					taints[i].Value = a.Value
+					replaced = true
+				}
+			}
+			if !replaced {
+				if a.Effect == object.TaintEffectNoExecute {
+					now := time.Now()
+					a.TimeAdded = &now
+				}
+				taints = append(taints, a)
+			}
+		}
+		node.Spec.Taints = taints
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Printf("node/%s tainted\n", args[1])
+}
+
+// parseTaint parses key[=value]:Effect, or key[:Effect]- in which case remove is true
+func parseTaint(arg string) (taint object.Taint, remove bool, err error) {
+	if strings.HasSuffix(arg, "-") {
+		remove = true
+		arg = strings.TrimSuffix(arg, "-")
+	}
+	keyValue := arg
+	if i := strings.LastIndex(arg, ":"); i != -1 {
+		keyValue, taint.Effect = arg[:i], arg[i+1:]
+	}
+	taint.Key = keyValue
+	if i := strings.Index(keyValue, "="); i != -1 {
+		taint.Key, taint.Value = keyValue[:i], keyValue[i+1:]
+	}
+	if taint.Key == "" {
+		return taint, remove, fmt.Errorf("invalid taint spec %q, the key is empty", arg)
+	}
+	switch taint.Effect {
+	case object.TaintEffectNoSchedule, object.TaintEffectPreferNoSchedule, object.TaintEffectNoExecute:
+	case "":
+		if !remove {
+			return taint, remove, fmt.Errorf("invalid taint spec %q, the effect is missing", arg)
+		}
+	default:
+		return taint, remove, fmt.Errorf("invalid taint effect %q, it must be NoSchedule, PreferNoSchedule or NoExecute", taint.Effect)
+	}
+	return taint, remove, nil
+}
+
+// findNodeIp returns the dynamicIp the node is registered with, the node is given by its name or its ip
+func findNodeIp(nameOrIp string) (string, error) {
+	listRes, err := client.Get(baseUrl + config.NODE_PREFIX)
+	if err != nil {
+		return "", err
+	}
+	for _, res := range listRes {
+		node := &object.Node{}
+		if err = json.Unmarshal(res.ValueBytes, node); err != nil {
+			continue
+		}
+		if node.MetaData.Name == nameOrIp || node.Spec.DynamicIp == nameOrIp {
+			return node.Spec.DynamicIp, nil
+		}
+	}
+	return "", errors.New("node " + nameOrIp + " not found")
+}
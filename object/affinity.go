@@ -0,0 +1,135 @@
+package object
+
+// the operators of LabelSelectorRequirement and NodeSelectorRequirement
+const (
+	SelectorOpIn           string = "In"
+	SelectorOpNotIn        string = "NotIn"
+	SelectorOpExists       string = "Exists"
+	SelectorOpDoesNotExist string = "DoesNotExist"
+
+	// LabelHostname is a topology key in which every node is its own domain, nodes need not be labelled with it
+	LabelHostname string = "kubernetes.io/hostname"
+)
+
+// Affinity are the constraints on the nodes a pod is scheduled on. The required ones filter the nodes,
+// the preferred ones make the scheduler favour some of them. Running pods are not evicted when they stop holding.
+type Affinity struct {
+	NodeAffinity    *NodeAffinity    `json:"nodeAffinity,omitempty" yaml:"nodeAffinity,omitempty"`
+	PodAntiAffinity *PodAntiAffinity `json:"podAntiAffinity,omitempty" yaml:"podAntiAffinity,omitempty"`
+}
+
+// NodeAffinity selects nodes by their labels
+type NodeAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution  *NodeSelector             `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty" yaml:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []PreferredSchedulingTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty" yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// NodeSelector matches a node if any of its terms matches it
+type NodeSelector struct {
+	NodeSelectorTerms []NodeSelectorTerm `json:"nodeSelectorTerms" yaml:"nodeSelectorTerms"`
+}
+
+// NodeSelectorTerm matches a node if all of its requirements hold, an empty term matches no node
+type NodeSelectorTerm struct {
+	MatchExpressions []NodeSelectorRequirement `json:"matchExpressions" yaml:"matchExpressions"`
+}
+
+// NodeSelectorRequirement tests the value of the node label Key, Operator is one of the SelectorOp constants
+type NodeSelectorRequirement struct {
+	Key      string   `json:"key" yaml:"key"`
+	Operator string   `json:"operator" yaml:"operator"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// PreferredSchedulingTerm adds Weight, 1-100, to the score of the nodes matching Preference
+type PreferredSchedulingTerm struct {
+	Weight     int32            `json:"weight" yaml:"weight"`
+	Preference NodeSelectorTerm `json:"preference" yaml:"preference"`
+}
+
+// PodAntiAffinity keeps a pod away from the pods it selects
+type PodAntiAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution  []PodAffinityTerm         `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty" yaml:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty" yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// PodAffinityTerm selects the pods matching LabelSelector in Namespaces, the namespace of the pod if empty.
+// Two nodes are in the same topology domain if they have the same value of the label TopologyKey,
+// e.g. LabelHostname for every node on its own.
+type PodAffinityTerm struct {
+	LabelSelector *LabelSelector `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+	Namespaces    []string       `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	TopologyKey   string         `json:"topologyKey" yaml:"topologyKey"`
+}
+
+// WeightedPodAffinityTerm subtracts Weight, 1-100, from the score of the nodes in the topology domain of a selected pod
+type WeightedPodAffinityTerm struct {
+	Weight          int32           `json:"weight" yaml:"weight"`
+	PodAffinityTerm PodAffinityTerm `json:"podAffinityTerm" yaml:"podAffinityTerm"`
+}
+
+// Matches is true if labels satisfy the selector, a nil selector matches nothing
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil {
+		return false
+	}
+	for k, v := range s.MatchLabels {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	for _, requirement := range s.MatchExpressions {
+		if !matchRequirement(labels, requirement.Key, requirement.Operator, requirement.Values) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches is true if the node labels satisfy every requirement of the term
+func (t *NodeSelectorTerm) Matches(labels map[string]string) bool {
+	if len(t.MatchExpressions) == 0 {
+		return false
+	}
+	for _, requirement := range t.MatchExpressions {
+		if !matchRequirement(labels, requirement.Key, requirement.Operator, requirement.Values) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches is true if the node labels satisfy any term of the selector
+func (s *NodeSelector) Matches(labels map[string]string) bool {
+	for i := range s.NodeSelectorTerms {
+		if s.NodeSelectorTerms[i].Matches(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRequirement(labels map[string]string, key string, operator string, values []string) bool {
+	value, ok := labels[key]
+	switch operator {
+	case SelectorOpIn:
+		return ok && contains(values, value)
+	case SelectorOpNotIn:
+		return !ok || !contains(values, value)
+	case SelectorOpExists:
+		return ok
+	case SelectorOpDoesNotExist:
+		return !ok
+	}
+	// an unknown operator matches nothing
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package object
+
+import "time"
+
+// the restart policies of a pod, they apply to all its containers
+const (
+	// RestartPolicyAlways restarts the containers whenever they exit, it is the default
+	RestartPolicyAlways string = "Always"
+	// RestartPolicyOnFailure restarts the containers exiting with a non zero code
+	RestartPolicyOnFailure string = "OnFailure"
+	// RestartPolicyNever leaves the containers exited, the pod ends Succeeded or Failed
+	RestartPolicyNever string = "Never"
+)
+
+// the reasons of the states of a container
+const (
+	ContainerReasonCreating         string = "ContainerCreating"
+	ContainerReasonCrashLoopBackOff string = "CrashLoopBackOff"
+	ContainerReasonCompleted        string = "Completed"
+	ContainerReasonError            string = "Error"
+	ContainerReasonOOMKilled        string = "OOMKilled"
+)
+
+// ContainerStatus is reported by the kubelet for every container of the pod
+type ContainerStatus struct {
+	Name string `json:"name" yaml:"name"`
+	// State is the current state of the container
+	State ContainerState `json:"state" yaml:"state"`
+	// LastTerminationState is how the container exited the last time, e.g. before it was restarted
+	LastTerminationState ContainerState `json:"lastState" yaml:"lastState"`
+	// Started is false until the startup probe of the container succeeds
+	Started bool `json:"started" yaml:"started"`
+	// Ready is true once the readiness probe of the container succeeds, or it is started if it has none
+	Ready bool `json:"ready" yaml:"ready"`
+	// RestartCount is how many times the container was restarted, after it exited or failed its liveness probe
+	RestartCount int32 `json:"restartCount" yaml:"restartCount"`
+}
+
+// ContainerState holds at most one of the states, none means unknown
+type ContainerState struct {
+	Waiting    *ContainerStateWaiting    `json:"waiting,omitempty" yaml:"waiting,omitempty"`
+	Running    *ContainerStateRunning    `json:"running,omitempty" yaml:"running,omitempty"`
+	Terminated *ContainerStateTerminated `json:"terminated,omitempty" yaml:"terminated,omitempty"`
+}
+
+type ContainerStateWaiting struct {
+	// Reason is e.g. ContainerCreating or CrashLoopBackOff
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+type ContainerStateRunning struct {
+	StartedAt time.Time `json:"startedAt" yaml:"startedAt"`
+}
+
+type ContainerStateTerminated struct {
+	ExitCode int32 `json:"exitCode" yaml:"exitCode"`
+	// Reason is Completed, Error or OOMKilled
+	Reason     string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Message    string    `json:"message,omitempty" yaml:"message,omitempty"`
+	StartedAt  time.Time `json:"startedAt" yaml:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt" yaml:"finishedAt"`
+}
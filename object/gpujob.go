@@ -9,7 +9,8 @@ import (
 )
 
 type Job2Pod struct {
-	PodName string
+	PodName   string
+	Namespace string
 }
 
 type GPUJob struct {
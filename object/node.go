@@ -0,0 +1,85 @@
+package object
+
+import "time"
+
+// the types of the conditions of a node
+const (
+	// NodeReady is True if the kubelet of the node is healthy, Unknown if it stopped posting heartbeats
+	NodeReady string = "Ready"
+)
+
+// the statuses of a condition
+const (
+	ConditionTrue    string = "True"
+	ConditionFalse   string = "False"
+	ConditionUnknown string = "Unknown"
+)
+
+// TaintNodeUnreachable is put by the node lifecycle controller on the nodes which stopped posting heartbeats
+const TaintNodeUnreachable string = "node.kubernetes.io/unreachable"
+
+// PodReasonNodeLost is the reason of a pod evicted from a node which stopped posting heartbeats
+const PodReasonNodeLost string = "NodeLost"
+
+// NodeCondition is the latest observation of one aspect of the node
+type NodeCondition struct {
+	Type   string `json:"type" yaml:"type"`
+	Status string `json:"status" yaml:"status"`
+	// LastHeartbeatTime is the last time the condition was updated
+	LastHeartbeatTime time.Time `json:"lastHeartbeatTime" yaml:"lastHeartbeatTime"`
+	// LastTransitionTime is the last time the status changed
+	LastTransitionTime time.Time `json:"lastTransitionTime" yaml:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Message            string    `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// GetCondition returns the condition of the type, nil if the node has none
+func (node *Node) GetCondition(conditionType string) *NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetCondition adds the condition or updates the one of the same type, the transition time
+// is now if the status changes and kept otherwise. It returns false if nothing changed.
+func (node *Node) SetCondition(condition NodeCondition, now time.Time) bool {
+	old := node.GetCondition(condition.Type)
+	if old == nil {
+		condition.LastTransitionTime = now
+		node.Status.Conditions = append(node.Status.Conditions, condition)
+		return true
+	}
+	if old.Status == condition.Status {
+		condition.LastTransitionTime = old.LastTransitionTime
+	} else {
+		condition.LastTransitionTime = now
+	}
+	if *old == condition {
+		return false
+	}
+	*old = condition
+	return true
+}
+
+// IsReady is true if the Ready condition of the node is True
+func (node *Node) IsReady() bool {
+	condition := node.GetCondition(NodeReady)
+	return condition != nil && condition.Status == ConditionTrue
+}
+
+// Lease is renewed by the kubelet of a node as its heartbeat, it is kept under the dynamicIp of the node
+type Lease struct {
+	ObjectMeta `json:"metadata" yaml:"metadata"`
+	Spec       LeaseSpec `json:"spec" yaml:"spec"`
+}
+
+type LeaseSpec struct {
+	// HolderIdentity is the name of the node
+	HolderIdentity string `json:"holderIdentity" yaml:"holderIdentity"`
+	// LeaseDurationSeconds is how long the lease lasts after RenewTime
+	LeaseDurationSeconds int32     `json:"leaseDurationSeconds" yaml:"leaseDurationSeconds"`
+	RenewTime            time.Time `json:"renewTime" yaml:"renewTime"`
+}
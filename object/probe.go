@@ -0,0 +1,87 @@
+package object
+
+import "time"
+
+// Probe is a health check the kubelet runs periodically against a container,
+// exactly one of Exec, HTTPGet and TCPSocket is set
+type Probe struct {
+	ProbeHandler `yaml:",inline"`
+	// InitialDelaySeconds is how long to wait after the container started before the first check
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty" yaml:"initialDelaySeconds,omitempty"`
+	// TimeoutSeconds is how long a check may take, 1 by default
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+	// PeriodSeconds is the interval of the checks, 10 by default
+	PeriodSeconds int32 `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+	// SuccessThreshold is how many checks in a row must succeed after a failure, 1 by default
+	SuccessThreshold int32 `json:"successThreshold,omitempty" yaml:"successThreshold,omitempty"`
+	// FailureThreshold is how many checks in a row must fail after a success, 3 by default
+	FailureThreshold int32 `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"`
+}
+
+type ProbeHandler struct {
+	// Exec succeeds if the command exits with 0 in the container
+	Exec *ExecAction `json:"exec,omitempty" yaml:"exec,omitempty"`
+	// HTTPGet succeeds if the response status is in [200, 400)
+	HTTPGet *HTTPGetAction `json:"httpGet,omitempty" yaml:"httpGet,omitempty"`
+	// TCPSocket succeeds if the port can be connected
+	TCPSocket *TCPSocketAction `json:"tcpSocket,omitempty" yaml:"tcpSocket,omitempty"`
+}
+
+type ExecAction struct {
+	Command []string `json:"command" yaml:"command"`
+}
+
+type HTTPGetAction struct {
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	Port int    `json:"port" yaml:"port"`
+	// Host is the ip of the pod by default
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+	// Scheme is HTTP or HTTPS, HTTP by default
+	Scheme      string       `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	HTTPHeaders []HTTPHeader `json:"httpHeaders,omitempty" yaml:"httpHeaders,omitempty"`
+}
+
+type HTTPHeader struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+type TCPSocketAction struct {
+	Port int `json:"port" yaml:"port"`
+	// Host is the ip of the pod by default
+	Host string `json:"host,omitempty" yaml:"host,omitempty"`
+}
+
+// the types of the conditions of a pod
+const (
+	// PodReady is True if the pod is running and all its containers are ready, the services only select such pods
+	PodReady string = "Ready"
+)
+
+// PodCondition is the latest observation of one aspect of the pod
+type PodCondition struct {
+	Type               string    `json:"type" yaml:"type"`
+	Status             string    `json:"status" yaml:"status"`
+	LastTransitionTime time.Time `json:"lastTransitionTime" yaml:"lastTransitionTime"`
+	Reason             string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Message            string    `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// GetCondition returns the condition of the type, nil if the pod has none
+func (pod *Pod) GetCondition(conditionType string) *PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsReady is true if the pod is running and its Ready condition is True
+func (pod *Pod) IsReady() bool {
+	if pod.Status.Phase != Running {
+		return false
+	}
+	condition := pod.GetCondition(PodReady)
+	return condition != nil && condition.Status == ConditionTrue
+}
@@ -0,0 +1,73 @@
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var memoryUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30},
+	{"K", 1 << 10}, {"k", 1 << 10}, {"M", 1 << 20}, {"m", 1 << 20}, {"G", 1 << 30}, {"g", 1 << 30},
+}
+
+// ParseCpu parses a cpu amount such as "0.5" or "500m" into millicores, "" is 0
+func ParseCpu(cpu string) (int64, error) {
+	cpu = strings.TrimSpace(cpu)
+	if cpu == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(cpu, "m") {
+		milli, err := strconv.ParseInt(strings.TrimSuffix(cpu, "m"), 10, 64)
+		if err != nil || milli < 0 {
+			return 0, fmt.Errorf("invalid cpu %q", cpu)
+		}
+		return milli, nil
+	}
+	cores, err := strconv.ParseFloat(cpu, 64)
+	if err != nil || cores < 0 {
+		return 0, fmt.Errorf("invalid cpu %q", cpu)
+	}
+	return int64(cores * 1000), nil
+}
+
+// ParseMemory parses a memory amount such as "512M", "2G", "100k" or a number of bytes into bytes, "" is 0.
+// As for docker, K M and G are powers of 1024.
+func ParseMemory(memory string) (int64, error) {
+	memory = strings.TrimSpace(memory)
+	if memory == "" {
+		return 0, nil
+	}
+	number, unit := memory, int64(1)
+	for _, u := range memoryUnits {
+		if strings.HasSuffix(memory, u.suffix) {
+			number, unit = strings.TrimSuffix(memory, u.suffix), u.bytes
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid memory %q", memory)
+	}
+	return int64(value * float64(unit)), nil
+}
+
+// ResourceRequests returns the cpu in millicores and the memory in bytes the containers of the pod are limited to
+func (pod *Pod) ResourceRequests() (cpu int64, memory int64, err error) {
+	for _, container := range pod.Spec.Containers {
+		c, err := ParseCpu(container.Limits.Cpu)
+		if err != nil {
+			return 0, 0, err
+		}
+		m, err := ParseMemory(container.Limits.Memory)
+		if err != nil {
+			return 0, 0, err
+		}
+		cpu += c
+		memory += m
+	}
+	return cpu, memory, nil
+}
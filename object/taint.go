@@ -0,0 +1,107 @@
+package object
+
+import (
+	"fmt"
+	"time"
+)
+
+// the effects of a taint on the pods which do not tolerate it
+const (
+	// TaintEffectNoSchedule keeps new pods off the node, the pods running on it stay
+	TaintEffectNoSchedule string = "NoSchedule"
+	// TaintEffectPreferNoSchedule makes the scheduler avoid the node
+	TaintEffectPreferNoSchedule string = "PreferNoSchedule"
+	// TaintEffectNoExecute keeps new pods off the node and evicts the pods running on it
+	TaintEffectNoExecute string = "NoExecute"
+)
+
+// the operators of a toleration
+const (
+	TolerationOpEqual  string = "Equal"
+	TolerationOpExists string = "Exists"
+)
+
+// Taint repels the pods which do not tolerate it from a node
+type Taint struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value,omitempty" yaml:"value,omitempty"`
+	Effect string `json:"effect" yaml:"effect"`
+	// TimeAdded is when a NoExecute taint was added, the TolerationSeconds of the pods count from it
+	TimeAdded *time.Time `json:"timeAdded,omitempty" yaml:"timeAdded,omitempty"`
+}
+
+func (t *Taint) ToString() string {
+	if t.Value == "" {
+		return fmt.Sprintf("%s:%s", t.Key, t.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", t.Key, t.Value, t.Effect)
+}
+
+// Toleration lets a pod on the nodes with the matching taints.
+// An empty Key with Exists tolerates every taint, an empty Effect tolerates every effect.
+type Toleration struct {
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Operator is Equal, the default, or Exists which matches any Value
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    string `json:"value,omitempty" yaml:"value,omitempty"`
+	Effect   string `json:"effect,omitempty" yaml:"effect,omitempty"`
+	// TolerationSeconds is how long a pod tolerates a NoExecute taint before it is evicted, forever if nil
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty" yaml:"tolerationSeconds,omitempty"`
+}
+
+// ToleratesTaint is true if the toleration matches the taint
+func (t *Toleration) ToleratesTaint(taint *Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Key != "" && t.Key != taint.Key {
+		return false
+	}
+	switch t.Operator {
+	case TolerationOpExists:
+		return true
+	case "", TolerationOpEqual:
+		return t.Key != "" && t.Value == taint.Value
+	}
+	return false
+}
+
+// FindToleration returns the first toleration of tolerations matching the taint
+func FindToleration(tolerations []Toleration, taint *Taint) (*Toleration, bool) {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return &tolerations[i], true
+		}
+	}
+	return nil, false
+}
+
+// EvictionTime tells whether a pod with the tolerations has to leave a node because of its NoExecute taints, and when.
+// The TolerationSeconds of a taint without TimeAdded count from since.
+func EvictionTime(tolerations []Toleration, taints []Taint, since time.Time) (time.Time, bool) {
+	var evictAt time.Time
+	evict := false
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect != TaintEffectNoExecute {
+			continue
+		}
+		toleration, ok := FindToleration(tolerations, taint)
+		if ok && toleration.TolerationSeconds == nil {
+			continue
+		}
+		at := time.Time{}
+		if ok {
+			added := since
+			if taint.TimeAdded != nil {
+				added = *taint.TimeAdded
+			}
+			at = added.Add(time.Duration(*toleration.TolerationSeconds) * time.Second)
+		}
+		if !evict || at.Before(evictAt) {
+			evictAt = at
+		}
+		evict = true
+	}
+	return evictAt, evict
+}
@@ -9,6 +9,9 @@ const (
 	Delete       string = "Delete"
 	PodExit      string = "Exited"
 
+	// PodReasonUnschedulable is the reason of a pending pod which fits no node
+	PodReasonUnschedulable string = "Unschedulable"
+
 	// SUCCESS http status code
 	SUCCESS int = 200
 	FAILED  int = 400
@@ -22,12 +25,17 @@ const (
 )
 
 type ObjectMeta struct {
-	Name   string            `json:"name" yaml:"name"`
-	Labels map[string]string `json:"labels" yaml:"labels"`
-	UID    string            `json:"uid" yaml:"uid"`
+	Name string `json:"name" yaml:"name"`
+	// Namespace defines the space within which each name must be unique, empty means "default"
+	Namespace string            `json:"namespace" yaml:"namespace"`
+	Labels    map[string]string `json:"labels" yaml:"labels"`
+	UID       string            `json:"uid" yaml:"uid"`
 
 	OwnerReferences []OwnerReference `json:"ownerReferences" yaml:"ownerReferences"`
 	Ctime           string
+	// ResourceVersion is the version the object was read at, it is never stored. A write carrying
+	// it fails with 409 Conflict if the object has been modified since then, empty means overwrite.
+	ResourceVersion string `json:"resourceVersion,omitempty" yaml:"resourceVersion,omitempty"`
 }
 
 // OwnerReference ownership for objects, e.g. replicaset and pods
@@ -39,6 +47,18 @@ type OwnerReference struct {
 	Controller bool `json:"controller" yaml:"controller"`
 }
 
+/*******************Namespace*************************/
+
+// Namespace provides a scope for names, objects of different namespaces never conflict.
+type Namespace struct {
+	MetaData ObjectMeta      `json:"metadata" yaml:"metadata"`
+	Status   NamespaceStatus `json:"status" yaml:"status"`
+}
+
+type NamespaceStatus struct {
+	Phase string `json:"phase" yaml:"phase"`
+}
+
 /*******************ReplicaSet*************************/
 
 // ReplicaSet ensures that a specified number of pod replicas are running at any given time.
@@ -58,7 +78,19 @@ type ReplicaSetStatus struct {
 	Replicas int32 `json:"replicas" yaml:"replicas"`
 }
 
+// LabelSelector matches the labels holding every MatchLabels pair and satisfying every MatchExpressions requirement,
+// an empty selector matches every labels
 type LabelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty" yaml:"matchLabels,omitempty"`
+	MatchExpressions []LabelSelectorRequirement `json:"matchExpressions,omitempty" yaml:"matchExpressions,omitempty"`
+}
+
+// LabelSelectorRequirement tests the value of the label Key, Operator is one of the SelectorOp constants.
+// Values must be empty for Exists and DoesNotExist.
+type LabelSelectorRequirement struct {
+	Key      string   `json:"key" yaml:"key"`
+	Operator string   `json:"operator" yaml:"operator"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
 }
 
 /*******************Pod*************************/
@@ -73,6 +105,14 @@ type PodSpec struct {
 	Volumes    []Volume    `json:"volumes" yaml:"volumes"`
 	Containers []Container `json:"containers" yaml:"containers"`
 	NodeName   string      `json:"nodeName" yaml:"nodeName"`
+	// NodeSelector are labels the node must have for the pod to be scheduled on it
+	NodeSelector map[string]string `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	// Affinity are the scheduling constraints of the pod, see affinity.go
+	Affinity *Affinity `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+	// Tolerations let the pod on the nodes with the matching taints
+	Tolerations []Toleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	// RestartPolicy is Always, OnFailure or Never, see container.go. Always by default.
+	RestartPolicy string `json:"restartPolicy,omitempty" yaml:"restartPolicy,omitempty"`
 }
 
 type PodStatus struct {
@@ -81,6 +121,12 @@ type PodStatus struct {
 	PodIP string `json:"podIP" yaml:"podIP"`
 	//error message
 	Err string `json:"err" yaml:"err"`
+	// Reason is a brief CamelCase reason of the phase, e.g. Unschedulable for a pending pod no node fits
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// Conditions are reported by the kubelet, see probe.go
+	Conditions []PodCondition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	// ContainerStatuses are reported by the kubelet in the order of the containers, see container.go
+	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty" yaml:"containerStatuses,omitempty"`
 }
 
 type PodTemplate struct {
@@ -110,6 +156,12 @@ type Container struct {
 	Limits       Limit         `json:"limits" yaml:"limits"`
 	Ports        []Port        `json:"ports" yaml:"ports"`
 	Env          []EnvEntry    `json:"env" yaml:"env"`
+	// LivenessProbe restarts the container when it fails
+	LivenessProbe *Probe `json:"livenessProbe,omitempty" yaml:"livenessProbe,omitempty"`
+	// ReadinessProbe keeps the pod out of the services while it fails
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty" yaml:"readinessProbe,omitempty"`
+	// StartupProbe holds the other probes back until it succeeds, the container is restarted if it fails
+	StartupProbe *Probe `json:"startupProbe,omitempty" yaml:"startupProbe,omitempty"`
 }
 
 type VolumeMount struct {
@@ -158,9 +210,17 @@ type NodeSpec struct {
 	DynamicIp string `json:"physicalIp" yaml:"physicalIp""`
 	//为该节点分配的pod网段
 	NodeIpAndMask string `json:"nodeIpAndMask" yaml:"nodeIpAndMask"`
+	// Taints repel the pods which do not tolerate them
+	Taints []Taint `json:"taints,omitempty" yaml:"taints,omitempty"`
 }
 
 type NodeStatus struct {
+	// Capacity is the total cpu and memory of the node
+	Capacity Limit `json:"capacity" yaml:"capacity"`
+	// Allocatable is the part of Capacity the pods can use, the scheduler fits the pods in it
+	Allocatable Limit `json:"allocatable" yaml:"allocatable"`
+	// Conditions are reported by the kubelet and the node lifecycle controller, see node.go
+	Conditions []NodeCondition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 }
 
 /****************Service****************************/
@@ -20,7 +20,7 @@ import (
 //对pod的删除通过修改pod配置文件里的phase为DELETED进行
 func (s *Server) deletePod(ctx *gin.Context) {
 	name := ctx.Param(config.ParamResourceName)
-	key := config.PodConfigPREFIX + "/" + name
+	key := config.NamespacedKey(config.PodConfigPREFIX, ctx.Param(config.ParamNamespace), name)
 	resList, err := s.store.Get(key)
 	if err != nil || len(resList) == 0 {
 		fmt.Printf("[deletePod] pod not exist:%s\n", name)
@@ -36,9 +36,10 @@ func (s *Server) deletePod(ctx *gin.Context) {
 	}
 	pod.Status.Phase = object.Delete
 	raw, _ := json.Marshal(pod)
-	err = s.store.Put(key, raw)
+	err = s.store.Update(key, raw, resList[0].ResourceVersion)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithWriteError(ctx, err)
+		return
 	}
 	ctx.Status(http.StatusOK)
 }
@@ -46,7 +47,7 @@ func (s *Server) deletePod(ctx *gin.Context) {
 //同上述对pod的删除
 func (s *Server) deleteService(ctx *gin.Context) {
 	name := ctx.Param(config.ParamResourceName)
-	key := config.ServiceConfigPrefix + "/" + name
+	key := config.NamespacedKey(config.ServiceConfigPrefix, ctx.Param(config.ParamNamespace), name)
 	resList, err := s.store.Get(key)
 	if err != nil || len(resList) == 0 {
 		fmt.Printf("[deleteService] service not exist:%s\n", name)
@@ -62,9 +63,10 @@ func (s *Server) deleteService(ctx *gin.Context) {
 	}
 	service.Status.Phase = object.Delete
 	raw, _ := json.Marshal(service)
-	err = s.store.Put(key, raw)
+	err = s.store.Update(key, raw, resList[0].ResourceVersion)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithWriteError(ctx, err)
+		return
 	}
 	ctx.Status(http.StatusOK)
 }
@@ -73,7 +75,7 @@ func (s *Server) deleteService(ctx *gin.Context) {
 // TODO: real deletion by replica set controller !
 func (s *Server) deleteRS(ctx *gin.Context) {
 	name := ctx.Param(config.ParamResourceName)
-	key := config.RSConfigPrefix + "/" + name
+	key := config.NamespacedKey(config.RSConfigPrefix, ctx.Param(config.ParamNamespace), name)
 	resList, err := s.store.Get(key)
 	if err != nil || len(resList) == 0 {
 		fmt.Printf("[deleteRS] rs not exist:%s\n", name)
@@ -100,9 +102,10 @@ func (s *Server) deleteRS(ctx *gin.Context) {
 	rs.Spec.Replicas = 0
 	raw, _ := json.Marshal(rs)
 	fmt.Printf("[deleteRS] put key %v\n", key)
-	err = s.store.Put(key, raw)
+	err = s.store.Update(key, raw, resList[0].ResourceVersion)
 	if err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithWriteError(ctx, err)
+		return
 	}
 	ctx.Status(http.StatusOK)
 }
@@ -121,6 +124,7 @@ func (s *Server) userAddPod(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	pod.Namespace = ctx.Param(config.ParamNamespace)
 	body, _ = json.Marshal(pod)
 	fmt.Printf("key:%v\n", key)
 
@@ -138,8 +142,9 @@ func (s *Server) userAddRS(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	rs.Namespace = ctx.Param(config.ParamNamespace)
 	body, _ = json.Marshal(rs)
-	err = s.store.Put(config.RSConfigPrefix+"/"+rs.Name, body)
+	err = s.store.Put(config.NamespacedKey(config.RSConfigPrefix, rs.Namespace, rs.Name), body)
 }
 
 //service part
@@ -153,10 +158,17 @@ func (s *Server) AddService(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	service.MetaData.Namespace = ctx.Param(config.ParamNamespace)
+	version, err := parseResourceVersion(service.MetaData.ResourceVersion)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	service.MetaData.ResourceVersion = ""
 	if service.Spec.Type == "" {
 		service.Spec.Type = object.ClusterIp
 	}
-	ok, ip := serviceConfigStore.JudgeAndAllocClusterIp(service.MetaData.Name, service.Spec.ClusterIp)
+	ok, ip := serviceConfigStore.JudgeAndAllocClusterIp(config.NamespacedName(service.MetaData.Namespace, service.MetaData.Name), service.Spec.ClusterIp)
 	if !ok {
 		fmt.Println("[AddService] ClusterIp illegal")
 		ctx.AbortWithStatus(http.StatusBadRequest)
@@ -170,10 +182,10 @@ func (s *Server) AddService(ctx *gin.Context) {
 		}
 	}
 	body, _ = json.Marshal(service)
-	err = s.store.Put(config.ServiceConfigPrefix+"/"+service.MetaData.Name, body)
+	err = s.write(config.NamespacedKey(config.ServiceConfigPrefix, service.MetaData.Namespace, service.MetaData.Name), body, version)
 	if err != nil {
 		fmt.Println("[AddService] etcd put fail")
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithWriteError(ctx, err)
 		return
 	}
 }
@@ -187,10 +199,18 @@ func (s *Server) AddPod(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	pod.Namespace = ctx.Param(config.ParamNamespace)
+	key := config.NamespacedKey(config.PodConfigPREFIX, pod.Namespace, pod.Name)
+	version, err := parseResourceVersion(pod.ResourceVersion)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	pod.ResourceVersion = ""
 	if pod.UID == "" {
 		//这种情况下，可能etcd里边存的旧的有UID, 取出来填上，或者就是新的，需要分配
 		//从etcd里取,看以前是否有过
-		res, err2 := s.store.Get(config.PodConfigPREFIX + "/" + pod.Name)
+		res, err2 := s.store.Get(key)
 		if err2 != nil {
 			ctx.AbortWithStatus(http.StatusBadRequest)
 			return
@@ -213,10 +233,10 @@ func (s *Server) AddPod(ctx *gin.Context) {
 	}
 	body, _ = json.Marshal(pod)
 
-	err = s.store.Put(config.PodConfigPREFIX+"/"+pod.Name, body)
+	err = s.write(key, body, version)
 	if err != nil {
 		fmt.Println("[AddService] etcd put fail")
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithWriteError(ctx, err)
 		return
 	}
 }
@@ -232,9 +252,17 @@ func (s *Server) AddDnsAndTrans(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	dnsAndTrans.MetaData.Namespace = ctx.Param(config.ParamNamespace)
+	key := config.NamespacedKey(config.DnsAndTransPrefix, dnsAndTrans.MetaData.Namespace, dnsAndTrans.MetaData.Name)
+	version, err := parseResourceVersion(dnsAndTrans.MetaData.ResourceVersion)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	dnsAndTrans.MetaData.ResourceVersion = ""
 	if dnsAndTrans.Status.Phase == "" {
 		//用户文件发来，此刻需要取出phase以及gateWayIp
-		old, err3 := s.store.Get(config.DnsAndTransPrefix + "/" + dnsAndTrans.MetaData.Name)
+		old, err3 := s.store.Get(key)
 		if err3 != nil {
 			fmt.Println("[AddDnsAndTrans] add fail " + err3.Error())
 			ctx.AbortWithStatus(http.StatusBadRequest)
@@ -249,8 +277,8 @@ func (s *Server) AddDnsAndTrans(ctx *gin.Context) {
 			}
 		}
 	}
-	//获取所有的service进行, 从而回填ip
-	res2, err2 := s.store.PrefixGet(config.ServicePrefix)
+	//获取同一namespace下所有的service进行, 从而回填ip
+	res2, err2 := s.store.PrefixGet(config.NamespacedPrefix(config.ServicePrefix, dnsAndTrans.MetaData.Namespace) + "/")
 	if err2 != nil {
 		fmt.Println("[AddDnsAndTrans] add fail " + err2.Error())
 		ctx.AbortWithStatus(http.StatusBadRequest)
@@ -286,10 +314,10 @@ func (s *Server) AddDnsAndTrans(ctx *gin.Context) {
 		}
 	}
 	body, _ = json.Marshal(dnsAndTrans)
-	err = s.store.Put(config.DnsAndTransPrefix+"/"+dnsAndTrans.MetaData.Name, body)
+	err = s.write(key, body, version)
 	if err != nil {
 		fmt.Println("[AddDnsAndTrans] etcd put fail")
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		abortWithWriteError(ctx, err)
 		return
 	}
 	return
@@ -303,11 +331,12 @@ func (s *Server) getActivePods(ctx *gin.Context) {
 	}
 
 	uid := ctx.Query("uid")
+	namespace := ctx.Query(config.ParamNamespace)
 
 	var expect int
 	var actual int
 
-	listRes, err := s.store.PrefixGet("/registry/pod/default")
+	listRes, err := s.store.PrefixGet(config.NamespacedPrefix(config.PodRuntimePrefix, namespace) + "/")
 	if err != nil {
 		fmt.Printf("[getActivePods] list fail\n")
 		ctx.Status(http.StatusBadRequest)
@@ -317,7 +346,7 @@ func (s *Server) getActivePods(ctx *gin.Context) {
 	activePods := controller.FilterActivePods(allPods)
 	actual = len(activePods)
 
-	key := "/registry/rs/default/" + rsName
+	key := config.NamespacedKey(config.RSPrefix, namespace, rsName)
 	raw, err := s.store.Get(key)
 	if err != nil {
 		fmt.Printf("[getActivePods] fail to get nodes\n")
@@ -385,8 +414,9 @@ func (s *Server) addVirtualSvc(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	vs.Namespace = ctx.Param(config.ParamNamespace)
 	body, _ = json.Marshal(vs)
-	err = s.store.Put(config.VirtualSvcPrefix+"/"+vs.Name, body)
+	err = s.store.Put(config.NamespacedKey(config.VirtualSvcPrefix, vs.Namespace, vs.Name), body)
 }
 
 func (s *Server) putJob2Pod(ctx *gin.Context) {
@@ -421,3 +451,57 @@ func (s *Server) getJob2Pod(ctx *gin.Context) {
 	data, err := json.Marshal(listResList)
 	ctx.Data(http.StatusOK, "application/json", data)
 }
+
+func (s *Server) addNamespace(ctx *gin.Context) {
+	name := ctx.Param(config.ParamResourceName)
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	ns := object.Namespace{}
+	err = json.Unmarshal(body, &ns)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	ns.MetaData.Name = name
+	if ns.MetaData.UID == "" {
+		ns.MetaData.UID = uuid.New().String()
+	}
+	if ns.MetaData.Ctime == "" {
+		ns.MetaData.Ctime = time.Now().Format("2006-01-02 15:04:05")
+	}
+	ns.Status.Phase = object.Running
+	body, _ = json.Marshal(ns)
+	err = s.store.Put(config.NAMESPACE_PREFIX+"/"+name, body)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// a namespace can only be deleted when it is empty, and default can never be deleted
+func (s *Server) deleteNamespace(ctx *gin.Context) {
+	name := ctx.Param(config.ParamResourceName)
+	if name == config.DefaultNamespace {
+		fmt.Printf("[deleteNamespace] can not delete namespace %s\n", name)
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	for _, root := range config.NamespacedRoots {
+		resList, err := s.store.PrefixGet(config.NamespacedPrefix(root, name) + "/")
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		if len(resList) != 0 {
+			fmt.Printf("[deleteNamespace] namespace %s is not empty\n", name)
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+	}
+	err := s.store.Del(config.NAMESPACE_PREFIX + "/" + name)
+	if err != nil {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
@@ -29,6 +29,8 @@ type Recover struct {
 
 type Ticket struct {
 	T uint64
+	// Queue is the queue to consume a resumed watch from, empty for the exchange of the watched key
+	Queue string `json:",omitempty"`
 }
 
 type Server struct {
@@ -41,6 +43,8 @@ type Server struct {
 	watcherMtx   sync.Mutex // watcherMtx 保护watcherCount
 	watcherChan  chan watchOpt
 	ticketSeller *atomic.Uint64
+	resumers     map[uint64]context.CancelFunc // resumers 每个resume的watch独占一个etcd watcher
+	resumerMtx   sync.Mutex
 }
 
 type watcher struct {
@@ -76,6 +80,7 @@ func NewServer(c *config.ServerConfig) (*Server, error) {
 		watcherMap:   map[string]*watcher{},
 		watcherChan:  watcherChan,
 		ticketSeller: atomic.NewUint64(0),
+		resumers:     map[uint64]context.CancelFunc{},
 		//kubeNetSupport: kubeNetSupport,
 	}
 
@@ -91,20 +96,24 @@ func NewServer(c *config.ServerConfig) (*Server, error) {
 	{
 		engine.GET(config.Path, s.validate, s.get)
 		engine.DELETE(config.Path, s.validate, s.del)
-		engine.PUT(config.Path, s.validate, s.put)
+		engine.PUT(config.Path, s.validate, s.checkNamespace, s.put)
 		engine.POST(config.Path, s.validate, s.watch)
 	}
 	{
 		engine.GET(config.PrefixPath, s.validate, s.prefixGet)
 		engine.POST(config.PrefixPath, s.validate, s.prefixWatch)
 	}
+	{
+		engine.GET(config.AllNamespacesPath, s.validate, s.prefixGet)
+		engine.POST(config.AllNamespacesPath, s.validate, s.prefixWatch)
+	}
 
 	{
 		engine.DELETE(config.RSConfig, s.deleteRS)
 
 	}
 	{
-		engine.PUT(config.PodCONFIG, s.AddPod)
+		engine.PUT(config.PodCONFIG, s.checkNamespace, s.AddPod)
 		engine.DELETE(config.PodCONFIG, s.deletePod)
 	}
 	{
@@ -117,27 +126,36 @@ func NewServer(c *config.ServerConfig) (*Server, error) {
 		engine.GET(config.NODE_PREFIX, s.prefixGet)
 		engine.POST(config.NODE_PREFIX, s.prefixWatch)
 	}
+	{
+		engine.PUT(config.NAMESPACE, s.addNamespace)
+		engine.DELETE(config.NAMESPACE, s.deleteNamespace)
+	}
 	{
 		// user operation
-		engine.PUT(config.UserPodPath, s.userAddPod)
-		engine.PUT(config.UserRSPath, s.userAddRS)
+		engine.PUT(config.UserPodPath, s.checkNamespace, s.userAddPod)
+		engine.PUT(config.UserRSPath, s.checkNamespace, s.userAddRS)
 	}
 	{
-		engine.PUT(config.ServiceConfig, s.AddService)
+		engine.PUT(config.ServiceConfig, s.checkNamespace, s.AddService)
 		engine.DELETE(config.ServiceConfig, s.deleteService)
 	}
 	{
-		engine.PUT(config.DnsAndTrans, s.AddDnsAndTrans)
+		engine.PUT(config.DnsAndTrans, s.checkNamespace, s.AddDnsAndTrans)
 		engine.GET(config.RS_POD, s.getActivePods)
 	}
 	{
-		engine.PUT(config.VirtualSvc, s.addVirtualSvc)
+		engine.PUT(config.VirtualSvc, s.checkNamespace, s.addVirtualSvc)
 	}
 	{
 		engine.GET(config.Job2PodPrefix, s.prefixGetJob2Pod)
 		engine.GET(config.Job2Pod, s.getJob2Pod)
 		engine.PUT(config.Job2Pod, s.putJob2Pod)
 	}
+	{
+		engine.GET(config.PodLog, s.getPodLog)
+		engine.GET(config.PodExec, s.execPod)
+		engine.GET(config.PodPortForward, s.portForwardPod)
+	}
 
 	go s.daemon(watcherChan)
 
@@ -161,6 +179,19 @@ func (s *Server) validate(c *gin.Context) {
 	}
 }
 
+// checkNamespace rejects writes into a namespace that has not been created
+func (s *Server) checkNamespace(c *gin.Context) {
+	namespace := c.Param(config.ParamNamespace)
+	if namespace == "" || namespace == config.DefaultNamespace {
+		return
+	}
+	resList, err := s.store.Get(config.NAMESPACE_PREFIX + "/" + namespace)
+	if err != nil || len(resList) == 0 {
+		klog.Warnf("namespace %s not found\n", namespace)
+		c.AbortWithStatus(http.StatusNotFound)
+	}
+}
+
 func (s *Server) get(ctx *gin.Context) {
 	key := ctx.Request.URL.Path
 	listRes, err := s.store.Get(key)
@@ -176,10 +207,17 @@ func (s *Server) put(ctx *gin.Context) {
 	body, err := ioutil.ReadAll(ctx.Request.Body)
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
 	}
-	err = s.store.Put(key, body)
+	version, body, err := popResourceVersion(body)
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	err = s.write(key, body, version)
+	if err != nil {
+		abortWithWriteError(ctx, err)
+		return
 	}
 	ctx.Status(http.StatusOK)
 }
@@ -195,11 +233,12 @@ func (s *Server) del(ctx *gin.Context) {
 
 func (s *Server) prefixGet(ctx *gin.Context) {
 	prefixKey := ctx.Request.URL.Path
-	listResList, err := s.store.PrefixGet(prefixKey)
+	listResList, revision, err := s.store.PrefixGetWithRevision(prefixKey + "/")
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 	}
 	data, err := json.Marshal(listResList)
+	ctx.Header(config.HeaderResourceVersion, strconv.FormatInt(revision, 10))
 	ctx.Data(http.StatusOK, "application/json", data)
 }
 
@@ -223,7 +262,9 @@ func (s *Server) watch(ctx *gin.Context) {
 	fmt.Println("watch match...")
 	key := ctx.Request.URL.Path
 	ticketStr, status := ctx.GetPostForm("ticket")
-	if !status {
+	if resourceVersion, ok := ctx.GetPostForm(config.ParamResourceVersion); !status && ok {
+		s.resumeWatch(ctx, key, false, resourceVersion)
+	} else if !status {
 		t := Ticket{}
 		t.T = s.ticketSeller.Add(1)
 		data, _ := json.Marshal(t)
@@ -235,6 +276,8 @@ func (s *Server) watch(ctx *gin.Context) {
 		if err != nil {
 			klog.Infof("%s\n", err.Error())
 			ctx.AbortWithStatus(http.StatusBadRequest)
+		} else if s.stopResumedWatch(key, ticket) {
+			ctx.Status(http.StatusOK)
 		} else {
 			if s.watcherMap[key] != nil {
 				s.watcherMap[key].set.Remove(ticket)
@@ -269,7 +312,9 @@ client收到返回值之后检查是否是200，如果是200
 func (s *Server) prefixWatch(ctx *gin.Context) {
 	key := ctx.Request.URL.Path
 	ticketStr, status := ctx.GetPostForm("ticket")
-	if !status {
+	if resourceVersion, ok := ctx.GetPostForm(config.ParamResourceVersion); !status && ok {
+		s.resumeWatch(ctx, key, true, resourceVersion)
+	} else if !status {
 		t := Ticket{}
 		t.T = s.ticketSeller.Add(1)
 		data, _ := json.Marshal(t)
@@ -281,6 +326,8 @@ func (s *Server) prefixWatch(ctx *gin.Context) {
 		if err != nil {
 			klog.Infof("%s\n", err.Error())
 			ctx.AbortWithStatus(http.StatusBadRequest)
+		} else if s.stopResumedWatch(key, ticket) {
+			ctx.Status(http.StatusOK)
 		} else {
 			if s.watcherMap[key] != nil {
 				s.watcherMap[key].set.Remove(ticket)
@@ -311,7 +358,8 @@ func (s *Server) daemon(listening <-chan watchOpt) {
 					var resChan <-chan etcdstore.WatchRes
 					w := &watcher{set: mapset.NewSet[uint64]()}
 					if withPrefix {
-						cancel, resChan = s.store.PrefixWatch(key)
+						// the trailing slash keeps /registry/pod/default from matching /registry/pod/default2
+						cancel, resChan = s.store.PrefixWatch(key + "/")
 					} else {
 						cancel, resChan = s.store.Watch(key)
 					}
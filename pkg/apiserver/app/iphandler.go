@@ -34,13 +34,28 @@ func (s *Server) addNode(ctx *gin.Context) {
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
+	key := config.NODE_PREFIX + "/" + dynamicIp
+	// a node carrying its resourceVersion is already registered, this is an update such as its taints
+	if node.MetaData.ResourceVersion != "" {
+		version, body, err := popResourceVersion(body)
+		if err != nil {
+			ctx.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		err = s.write(key, body, version)
+		if err != nil {
+			abortWithWriteError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusOK)
+		return
+	}
 	node, err = nodeConfigStore.AddNewNode(node)
 	if err != nil {
 		klog.Errorf("%s, %s", time.Now().Format("2006-01-02 15:04:05"), err.Error())
 		ctx.AbortWithStatus(http.StatusBadRequest)
 		return
 	}
-	key := config.NODE_PREFIX + "/" + dynamicIp
 	raw, _ := json.Marshal(node)
 	err = s.store.Put(key, raw)
 	if err != nil {
@@ -57,6 +72,9 @@ func (s *Server) deleteNode(ctx *gin.Context) {
 	err := s.store.Del(key)
 	if err != nil {
 		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
 	}
+	// the heartbeats of a node go with it
+	_ = s.store.Del(config.LEASE_PREFIX + "/" + physicalIp)
 	ctx.Status(http.StatusOK)
 }
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/klog"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+)
+
+// getPodLog streams the logs of a container of the pod from the kubelet of its node
+func (s *Server) getPodLog(ctx *gin.Context) {
+	s.proxyToKubelet(ctx, config.ContainerLogs)
+}
+
+// execPod and portForwardPod upgrade to websockets, which the proxy passes on both ways
+func (s *Server) execPod(ctx *gin.Context) {
+	s.proxyToKubelet(ctx, config.ContainerExec)
+}
+
+func (s *Server) portForwardPod(ctx *gin.Context) {
+	s.proxyToKubelet(ctx, config.PortForward)
+}
+
+// proxyToKubelet passes the request about the pod in the path to the kubelet of its node,
+// kubeletPath is the route of the kubelet api taking the namespace and the name of the pod too
+func (s *Server) proxyToKubelet(ctx *gin.Context, kubeletPath string) {
+	namespace := ctx.Param(config.ParamNamespace)
+	name := ctx.Param(config.ParamResourceName)
+	resList, err := s.store.Get(config.NamespacedKey(config.PodConfigPREFIX, namespace, name))
+	if err != nil || len(resList) == 0 {
+		ctx.String(http.StatusNotFound, "pod %s not found", config.NamespacedName(namespace, name))
+		return
+	}
+	pod := &object.Pod{}
+	if err = json.Unmarshal(resList[0].ValueBytes, pod); err != nil {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	if pod.Spec.NodeName == "" {
+		ctx.String(http.StatusBadRequest, "pod %s is not scheduled to a node yet", pod.Name)
+		return
+	}
+	nodeIp, err := s.nodeIp(pod.Spec.NodeName)
+	if err != nil {
+		ctx.String(http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	path := strings.Replace(kubeletPath, ":"+config.ParamNamespace, namespace, 1)
+	path = strings.Replace(path, ":"+config.ParamResourceName, name, 1)
+	host := fmt.Sprintf("%s:%d", nodeIp, config.KubeletPort)
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = host
+			req.URL.Path = path
+			req.Host = host
+		},
+		// followed logs are passed on as soon as they come
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			klog.Errorf("proxy to kubelet %s fail: %s\n", host, err.Error())
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("kubelet of node " + pod.Spec.NodeName + " is unreachable: " + err.Error()))
+		},
+	}
+	proxy.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// nodeIp returns the ip of the node named nodeName
+func (s *Server) nodeIp(nodeName string) (string, error) {
+	resList, err := s.store.PrefixGet(config.NODE_PREFIX + "/")
+	if err != nil {
+		return "", err
+	}
+	for _, res := range resList {
+		node := &object.Node{}
+		if err = json.Unmarshal(res.ValueBytes, node); err != nil {
+			continue
+		}
+		if node.MetaData.Name == nodeName {
+			return node.Spec.DynamicIp, nil
+		}
+	}
+	return "", fmt.Errorf("node %s not found", nodeName)
+}
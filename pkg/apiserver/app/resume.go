@@ -0,0 +1,99 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/klog"
+	"net/http"
+)
+
+// resumeQueue is the queue a resumed watch is published to, it only belongs to the ticket
+func resumeQueue(key string, ticket uint64) string {
+	return fmt.Sprintf("%s#%d", key, ticket)
+}
+
+/*
+resumeWatch
+watch请求附带form-data参数resourceVersion时，从该版本之后开始watch，返回值为200+T。
+
+与普通的watch不同，server为这个ticket单独建立一个etcd watcher，
+先重放resourceVersion之后的事件，再继续发布新的事件。
+事件发布到Ticket.Queue队列中，client直到订阅之前的事件都会保存在队列里。
+
+resourceVersion对应的版本已经被压缩时返回410，client需要重新list。
+结束时同样需要附带ticket参数POST之前的路径。
+*/
+func (s *Server) resumeWatch(ctx *gin.Context, key string, withPrefix bool, resourceVersionStr string) {
+	resourceVersion, err := parseResourceVersion(resourceVersionStr)
+	if err != nil || resourceVersion <= 0 {
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	watchKey := key
+	if withPrefix {
+		// same as prefixWatch, the trailing slash keeps /registry/pod/default from matching /registry/pod/default2
+		watchKey = key + "/"
+	}
+	cancel, resChan, err := s.store.WatchFrom(watchKey, resourceVersion, withPrefix)
+	if errors.Is(err, etcdstore.ErrCompacted) {
+		klog.Warnf("resume watching %s: %s\n", key, err.Error())
+		ctx.AbortWithStatus(http.StatusGone)
+		return
+	}
+	if err != nil {
+		klog.Errorf("%v\n", err)
+		ctx.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	t := Ticket{T: s.ticketSeller.Add(1)}
+	t.Queue = resumeQueue(key, t.T)
+	// the queue must exist before the first replayed event is published
+	if err = s.publisher.DeclareQueue(t.Queue); err != nil {
+		cancel()
+		klog.Errorf("%v\n", err)
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	s.resumerMtx.Lock()
+	s.resumers[t.T] = cancel
+	s.resumerMtx.Unlock()
+
+	go func(queue string, resChan <-chan etcdstore.WatchRes) {
+		for res := range resChan {
+			data, err := json.Marshal(res)
+			if err != nil {
+				klog.Errorf("%v\n", err)
+			}
+			err = s.publisher.Publish(queue, data, "application/json")
+			if err != nil {
+				klog.Errorf("%v\n", err)
+			}
+		}
+		if err := s.publisher.DeleteQueue(queue); err != nil {
+			klog.Errorf("%v\n", err)
+		}
+		klog.Infof("Res Chan closed for resumed watch %s\n", queue)
+	}(t.Queue, resChan)
+
+	data, _ := json.Marshal(t)
+	ctx.Data(http.StatusOK, "application/json", data)
+}
+
+// stopResumedWatch cancels the watcher of a ticket sold by resumeWatch,
+// it returns false if the ticket belongs to a shared watcher
+func (s *Server) stopResumedWatch(key string, ticket uint64) bool {
+	s.resumerMtx.Lock()
+	defer s.resumerMtx.Unlock()
+	cancel, ok := s.resumers[ticket]
+	if !ok {
+		return false
+	}
+	delete(s.resumers, ticket)
+	cancel()
+	klog.Infof("Cancel the resumed watcher of key %s ticket %d\n", key, ticket)
+	return true
+}
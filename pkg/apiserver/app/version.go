@@ -0,0 +1,65 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"minik8s/pkg/etcdstore"
+	"net/http"
+	"strconv"
+)
+
+const metadataField = "metadata"
+const resourceVersionField = "resourceVersion"
+
+// parseResourceVersion turns the resourceVersion of an object into an etcd mod revision,
+// 0 means the object does not care about the version
+func parseResourceVersion(resourceVersion string) (int64, error) {
+	if resourceVersion == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(resourceVersion, 10, 64)
+}
+
+// popResourceVersion takes metadata.resourceVersion out of a raw object.
+// The version is never stored since it is always the mod revision of the key.
+// Bodies which are not objects with metadata are returned as they are.
+func popResourceVersion(body []byte) (int64, []byte, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil || obj[metadataField] == nil {
+		return 0, body, nil
+	}
+	var meta map[string]json.RawMessage
+	if err := json.Unmarshal(obj[metadataField], &meta); err != nil || meta[resourceVersionField] == nil {
+		return 0, body, nil
+	}
+	var resourceVersion string
+	if err := json.Unmarshal(meta[resourceVersionField], &resourceVersion); err != nil {
+		return 0, nil, err
+	}
+	version, err := parseResourceVersion(resourceVersion)
+	if err != nil {
+		return 0, nil, err
+	}
+	delete(meta, resourceVersionField)
+	obj[metadataField], _ = json.Marshal(meta)
+	body, err = json.Marshal(obj)
+	return version, body, err
+}
+
+// write puts val under key, it is a compare-and-swap if resourceVersion is not 0
+func (s *Server) write(key string, val []byte, resourceVersion int64) error {
+	if resourceVersion == 0 {
+		return s.store.Put(key, val)
+	}
+	return s.store.Update(key, val, resourceVersion)
+}
+
+// abortWithWriteError answers 409 for a stale resourceVersion and 400 for the others
+func abortWithWriteError(ctx *gin.Context, err error) {
+	if errors.Is(err, etcdstore.ErrConflict) {
+		ctx.AbortWithStatus(http.StatusConflict)
+		return
+	}
+	ctx.AbortWithStatus(http.StatusBadRequest)
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"minik8s/pkg/messaging"
+	"path"
 	"time"
 )
 
@@ -37,8 +38,22 @@ const Recover = "/recover"
 
 const Path = "/registry/:resource/:namespace/:resourceName"
 const PrefixPath = "/registry/:resource/:namespace"
+
+// AllNamespacesPath lists or watches a resource across every namespace
+const AllNamespacesPath = "/registry/:resource"
 const ParamResource = "resource"
+const ParamNamespace = "namespace"
 const ParamResourceName = "resourceName"
+
+// ParamResourceVersion is the form parameter of a watch request resuming right after that version
+const ParamResourceVersion = "resourceVersion"
+
+// HeaderResourceVersion is the response header of a list carrying the version the list was read at
+const HeaderResourceVersion = "X-Resource-Version"
+
+// DefaultNamespace is used when an object does not specify its namespace.
+// Cluster scoped resources such as nodes and namespaces are kept under it too.
+const DefaultNamespace = "default"
 const ParamType = "type"
 const NODE_NAME = "name"
 
@@ -48,39 +63,97 @@ const UserPodPath = "/user/registry/pod/:namespace/:resourceName"
 const UserRSPath = "/user/registry/rs/:namespace/:resourceName"
 
 // path for kube client
+//
+// XXX and XXX_PREFIX pairs of cluster scoped resources point to DefaultNamespace directly.
+// The other prefixes are the roots of namespaced resources, use NamespacedPrefix and
+// NamespacedKey to get the real key. Watching a root watches all the namespaces.
 const (
-	RS               = "/registry/rs/default/:resourceName"
-	PodRuntime       = "/registry/pod/default/:resourceName"
-	PodRuntimePrefix = "/registry/pod/default"
+	RS               = "/registry/rs/:namespace/:resourceName"
+	RSPrefix         = "/registry/rs"
+	PodRuntime       = "/registry/pod/:namespace/:resourceName"
+	PodRuntimePrefix = "/registry/pod"
 	NODE             = "/registry/node/default/:resourceName"
 	NODE_PREFIX      = "/registry/node/default"
 
-	PodCONFIG       = "/registry/podConfig/default/:resourceName"
-	PodConfigPREFIX = "/registry/podConfig/default"
+	// LEASE_PREFIX keeps the heartbeat of every node under its dynamicIp, as NODE_PREFIX does
+	LEASE        = "/registry/lease/default/:resourceName"
+	LEASE_PREFIX = "/registry/lease/default"
+
+	NAMESPACE        = "/registry/namespace/default/:resourceName"
+	NAMESPACE_PREFIX = "/registry/namespace/default"
+
+	PodCONFIG       = "/registry/podConfig/:namespace/:resourceName"
+	PodConfigPREFIX = "/registry/podConfig"
 
-	ServiceConfig       = "/registry/serviceConfig/default/:resourceName"
-	ServiceConfigPrefix = "/registry/serviceConfig/default"
-	Service             = "/registry/service/default/:resourceName"
-	ServicePrefix       = "/registry/service/default"
+	ServiceConfig       = "/registry/serviceConfig/:namespace/:resourceName"
+	ServiceConfigPrefix = "/registry/serviceConfig"
+	Service             = "/registry/service/:namespace/:resourceName"
+	ServicePrefix       = "/registry/service"
 
-	RSConfig       = "/registry/rsConfig/default/:resourceName"
-	RSConfigPrefix = "/registry/rsConfig/default"
+	RSConfig       = "/registry/rsConfig/:namespace/:resourceName"
+	RSConfigPrefix = "/registry/rsConfig"
+
+	DeploymentPrefix = "/registry/deployment"
+	AutoscalerPrefix = "/registry/autoscaler"
+	JobPrefix        = "/registry/job"
 
 	SharedData       = "/registry/sharedData/default/:resourceName"
 	SharedDataPrefix = "/registry/sharedData/default"
 
-	DnsAndTrans       = "/registry/dnsAndTrans/default/:resourceName"
-	DnsAndTransPrefix = "/registry/dnsAndTrans/default"
+	DnsAndTrans       = "/registry/dnsAndTrans/:namespace/:resourceName"
+	DnsAndTransPrefix = "/registry/dnsAndTrans"
 
-	VirtualSvc       = "/registry/virtualSvc/default/:resourceName"
-	VirtualSvcPrefix = "/registry/virtualSvc/default"
+	VirtualSvc       = "/registry/virtualSvc/:namespace/:resourceName"
+	VirtualSvcPrefix = "/registry/virtualSvc"
 	RS_POD           = "/rs/pod"
 
 	Job2PodPrefix = "/job/pod"
 	Job2Pod       = "/job/pod/:resourceName"
+
+	// PodLog streams the logs of a container of the pod from the kubelet of its node
+	PodLog = "/log/pod/:namespace/:resourceName"
+	// PodExec and PodPortForward are websockets to the kubelet of the node of the pod
+	PodExec        = "/exec/pod/:namespace/:resourceName"
+	PodPortForward = "/portForward/pod/:namespace/:resourceName"
 )
 
-var defaultValidResources = []string{"pod", "rs", "deployment", "node", "test", "autoscaler", "podConfig", "sharedData", "service", "job", "serviceConfig", "rsConfig", "dnsAndTrans", "virtualSvc"}
+// KubeletPort is the port of the http api every kubelet serves the containers of its pods on,
+// the apiserver proxies the requests about the containers of a pod to the kubelet of its node
+const KubeletPort = 10250
+
+// paths of the kubelet api
+const (
+	ContainerLogs = "/containerLogs/:namespace/:resourceName"
+	ContainerExec = "/exec/:namespace/:resourceName"
+	PortForward   = "/portForward/:namespace/:resourceName"
+)
+
+// NamespacedRoots are the roots of the user facing namespaced resources.
+// A namespace can only be deleted when it holds none of them.
+var NamespacedRoots = []string{PodConfigPREFIX, RSConfigPrefix, ServiceConfigPrefix, DeploymentPrefix, AutoscalerPrefix, JobPrefix, DnsAndTransPrefix}
+
+// NamespacedPrefix returns the prefix of all the objects under root in namespace
+func NamespacedPrefix(root string, namespace string) string {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return path.Join(root, namespace)
+}
+
+// NamespacedKey returns the key of the object named name under root in namespace
+func NamespacedKey(root string, namespace string, name string) string {
+	return path.Join(NamespacedPrefix(root, namespace), name)
+}
+
+// NamespacedName is the unique name of an object across namespaces, e.g. default/nginx
+func NamespacedName(namespace string, name string) string {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return namespace + "/" + name
+}
+
+var defaultValidResources = []string{"pod", "rs", "deployment", "node", "test", "autoscaler", "podConfig", "sharedData", "service", "job", "serviceConfig", "rsConfig", "dnsAndTrans", "virtualSvc", "namespace", "lease"}
 
 type ServerConfig struct {
 	HttpPort       int
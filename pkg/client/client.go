@@ -35,10 +35,11 @@ func DefaultClientConfig() Config {
 
 func (r RESTClient) CreateRSPod(ctx context.Context, rs *object.ReplicaSet) error {
 	podUID := uuid.NewUUID(5)
-	attachURL := config.PodConfigPREFIX + "/" + rs.Spec.Template.Name + podUID
+	attachURL := config.NamespacedKey(config.PodConfigPREFIX, rs.Namespace, rs.Spec.Template.Name+podUID)
 
 	pod, _ := GetPodFromRS(rs)
 	pod.Name = rs.Spec.Template.Name + podUID
+	pod.Namespace = rs.Namespace
 	pod.UID = podUID
 	podRaw, _ := json.Marshal(pod)
 	reqBody := bytes.NewBuffer(podRaw)
@@ -64,7 +65,7 @@ func (r RESTClient) CreateRSPod(ctx context.Context, rs *object.ReplicaSet) erro
 }
 
 func (r RESTClient) UpdateRuntimePod(pod *object.Pod) error {
-	attachURL := "/registry/pod/default/" + pod.Name
+	attachURL := config.NamespacedKey(config.PodRuntimePrefix, pod.Namespace, pod.Name)
 	err := Put(r.Base+attachURL, pod)
 	if err != nil {
 		return err
@@ -72,24 +73,24 @@ func (r RESTClient) UpdateRuntimePod(pod *object.Pod) error {
 	return nil
 }
 
-func (r RESTClient) DeleteRuntimePod(podName string) error {
-	attachURL := "/registry/pod/default/" + podName
+func (r RESTClient) DeleteRuntimePod(namespace string, podName string) error {
+	attachURL := config.NamespacedKey(config.PodRuntimePrefix, namespace, podName)
 	err := Del(r.Base + attachURL)
 	return err
 }
 func (r RESTClient) UpdateConfigPod(pod *object.Pod) error {
-	attachURL := config.PodConfigPREFIX + "/" + pod.Name
+	attachURL := config.NamespacedKey(config.PodConfigPREFIX, pod.Namespace, pod.Name)
 	err := Put(r.Base+attachURL, pod)
 	return err
 }
-func (r RESTClient) DeleteConfigPod(podName string) error {
-	attachURL := config.PodConfigPREFIX + "/" + podName
+func (r RESTClient) DeleteConfigPod(namespace string, podName string) error {
+	attachURL := config.NamespacedKey(config.PodConfigPREFIX, namespace, podName)
 	err := Del(r.Base + attachURL)
 	return err
 }
 
-func (r RESTClient) GetConfigPod(name string) (*object.Pod, error) {
-	attachUrl := config.PodConfigPREFIX + "/" + name
+func (r RESTClient) GetConfigPod(namespace string, name string) (*object.Pod, error) {
+	attachUrl := config.NamespacedKey(config.PodConfigPREFIX, namespace, name)
 	resp, err := Get(r.Base + attachUrl)
 	if err != nil {
 		return nil, err
@@ -119,8 +120,8 @@ func GetPodFromRS(rs *object.ReplicaSet) (*object.Pod, error) {
 	return pod, nil
 }
 
-func (r RESTClient) GetRuntimePod(name string) (*object.Pod, error) {
-	attachUrl := "/registry/pod/default/" + name
+func (r RESTClient) GetRuntimePod(namespace string, name string) (*object.Pod, error) {
+	attachUrl := config.NamespacedKey(config.PodRuntimePrefix, namespace, name)
 	resp, err := Get(r.Base + attachUrl)
 	if err != nil {
 		return nil, err
@@ -135,8 +136,8 @@ func (r RESTClient) GetRuntimePod(name string) (*object.Pod, error) {
 
 /********************************RS*****************************/
 
-func GetRuntimeRS(ls *listerwatcher.ListerWatcher, name string) (*object.ReplicaSet, error) {
-	attachURL := "/registry/rs/default/" + name
+func GetRuntimeRS(ls *listerwatcher.ListerWatcher, namespace string, name string) (*object.ReplicaSet, error) {
+	attachURL := config.NamespacedKey(config.RSPrefix, namespace, name)
 
 	raw, err := ls.List(attachURL)
 	if err != nil {
@@ -158,8 +159,9 @@ func GetRuntimeRS(ls *listerwatcher.ListerWatcher, name string) (*object.Replica
 	return result, nil
 }
 
-func GetRSPods(ls *listerwatcher.ListerWatcher, name string, UID string) ([]*object.Pod, error) {
-	raw, err := ls.List("/registry/pod/default")
+// GetRSPods returns the pods owned by the rs, they always live in the namespace of the rs
+func GetRSPods(ls *listerwatcher.ListerWatcher, namespace string, name string, UID string) ([]*object.Pod, error) {
+	raw, err := ls.List(config.NamespacedPrefix(config.PodRuntimePrefix, namespace))
 	if err != nil {
 		fmt.Printf("[GetRSPods] list fail\n")
 		return nil, err
@@ -190,17 +192,17 @@ func MakePods(raw []etcdstore.ListRes, name string, UID string) ([]*object.Pod,
 	return pods, nil
 }
 func (r RESTClient) AddConfigRs(rs *object.ReplicaSet) error {
-	attachUrl := config.RSConfigPrefix + "/" + rs.Name
+	attachUrl := config.NamespacedKey(config.RSConfigPrefix, rs.Namespace, rs.Name)
 	err := Put(r.Base+attachUrl, rs)
 	return err
 }
-func (r RESTClient) DeleteConfigRs(rsName string) error {
-	attachUrl := config.RSConfigPrefix + "/" + rsName
+func (r RESTClient) DeleteConfigRs(namespace string, rsName string) error {
+	attachUrl := config.NamespacedKey(config.RSConfigPrefix, namespace, rsName)
 	err := Del(r.Base + attachUrl)
 	return err
 }
-func (r RESTClient) DeleteRS(rsName string) error {
-	attachURL := "/registry/rs/default/" + rsName
+func (r RESTClient) DeleteRS(namespace string, rsName string) error {
+	attachURL := config.NamespacedKey(config.RSPrefix, namespace, rsName)
 	fmt.Printf("delete rs:" + attachURL + "\n")
 	err := Del(r.Base + attachURL)
 	return err
@@ -235,7 +237,7 @@ func OwnByRs(pod *object.Pod) (exist bool, name string, UID string) {
 /********************************Node*****************************/
 
 func GetNodes(ls *listerwatcher.ListerWatcher) ([]*object.Node, error) {
-	raw, err := ls.List("/registry/node/default")
+	raw, err := ls.List(config.NODE_PREFIX)
 	if err != nil {
 		fmt.Printf("[GetNodes] fail to get nodes\n")
 	}
@@ -271,19 +273,25 @@ func (r RESTClient) GetNode(ip string) (*object.Node, error) {
 	return result, err
 }
 
+// RenewLease writes the heartbeat of the node registered with the dynamicIp
+func (r RESTClient) RenewLease(dynamicIp string, lease *object.Lease) error {
+	attachUrl := config.LEASE_PREFIX + "/" + dynamicIp
+	return Put(r.Base+attachUrl, lease)
+}
+
 /*******************************Service**********************************/
 func (r RESTClient) UpdateService(service *object.Service) error {
-	attachUrl := config.ServiceConfigPrefix + "/" + service.MetaData.Name
+	attachUrl := config.NamespacedKey(config.ServiceConfigPrefix, service.MetaData.Namespace, service.MetaData.Name)
 	err := Put(r.Base+attachUrl, service)
 	return err
 }
 func (r RESTClient) UpdateRuntimeService(service *object.Service) error {
-	attachUrl := config.ServicePrefix + "/" + service.MetaData.Name
+	attachUrl := config.NamespacedKey(config.ServicePrefix, service.MetaData.Namespace, service.MetaData.Name)
 	err := Put(r.Base+attachUrl, service)
 	return err
 }
-func (r RESTClient) GetRuntimeService(name string) (*object.Service, error) {
-	attachUrl := config.ServicePrefix + "/" + name
+func (r RESTClient) GetRuntimeService(namespace string, name string) (*object.Service, error) {
+	attachUrl := config.NamespacedKey(config.ServicePrefix, namespace, name)
 	resp, err := Get(r.Base + attachUrl)
 	if err != nil {
 		return nil, err
@@ -295,20 +303,20 @@ func (r RESTClient) GetRuntimeService(name string) (*object.Service, error) {
 	err = json.Unmarshal(resp[0].ValueBytes, result)
 	return result, err
 }
-func (r RESTClient) DeleteService(name string) error {
-	attachUrl := config.ServiceConfigPrefix + "/" + name
+func (r RESTClient) DeleteService(namespace string, name string) error {
+	attachUrl := config.NamespacedKey(config.ServiceConfigPrefix, namespace, name)
 	err := Del(r.Base + attachUrl)
 	return err
 }
-func (r RESTClient) DeleteRuntimeService(name string) error {
-	attachUrl := config.ServicePrefix + "/" + name
+func (r RESTClient) DeleteRuntimeService(namespace string, name string) error {
+	attachUrl := config.NamespacedKey(config.ServicePrefix, namespace, name)
 	err := Del(r.Base + attachUrl)
 	return err
 }
 
 /***************************DnsAndTrans************************************/
 func (r RESTClient) UpdateDnsAndTrans(trans *object.DnsAndTrans) error {
-	attachUrl := config.DnsAndTransPrefix + "/" + trans.MetaData.Name
+	attachUrl := config.NamespacedKey(config.DnsAndTransPrefix, trans.MetaData.Namespace, trans.MetaData.Name)
 	err := Put(r.Base+attachUrl, trans)
 	return err
 }
@@ -316,8 +324,8 @@ func (r RESTClient) UpdateDnsAndTrans(trans *object.DnsAndTrans) error {
 /********************************watch*****************************/
 
 // WatchRegister get ticket for message queue
-func (r RESTClient) WatchRegister(resource string, name string, withPrefix bool) (*string, *int64, error) {
-	attachURL := "/" + resource + "/default"
+func (r RESTClient) WatchRegister(resource string, namespace string, name string, withPrefix bool) (*string, *int64, error) {
+	attachURL := config.NamespacedPrefix("/"+resource, namespace)
 	if !withPrefix {
 		attachURL += "/" + name
 	}
@@ -367,6 +375,9 @@ func (r RESTClient) PutWrap(attachUrl string, requestBody any) error {
 		if err3 != nil {
 			return err3
 		}
+		if resp.StatusCode == http.StatusConflict {
+			return ErrConflict
+		}
 		if resp.StatusCode != 200 {
 			s := fmt.Sprintf("http request error, attachUrl = %s, StatusCode = %d", attachUrl, resp.StatusCode)
 			return errors.New(s)
@@ -113,6 +113,9 @@ func Put(url string, obj any) error {
 	if err != nil {
 		return err
 	}
+	if response.StatusCode == http.StatusConflict {
+		return ErrConflict
+	}
 	if response.StatusCode != http.StatusOK {
 		return errors.New("StatusCode not 200")
 	}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
+	"strconv"
+	"time"
+)
+
+// ErrConflict is returned by Put when the resourceVersion carried by the object is stale
+var ErrConflict = errors.New("StatusCode 409, resource version conflict")
+
+// ErrNotFound is returned by GuaranteedUpdate when there is nothing to update
+var ErrNotFound = errors.New("resource not found")
+
+// Backoff tells how many times and how long to wait between two retries
+type Backoff struct {
+	Steps    int
+	Duration time.Duration
+	Factor   float64
+}
+
+// DefaultRetry is enough for the controllers racing with each other
+var DefaultRetry = Backoff{
+	Steps:    5,
+	Duration: 10 * time.Millisecond,
+	Factor:   2.0,
+}
+
+// RetryOnConflict runs fn until it does not fail with ErrConflict or the steps are used up.
+// fn must get the latest object by itself, otherwise it will conflict again.
+func RetryOnConflict(backoff Backoff, fn func() error) error {
+	var err error
+	delay := backoff.Duration
+	for i := 0; i < backoff.Steps; i++ {
+		if i != 0 {
+			time.Sleep(delay)
+			delay = time.Duration(float64(delay) * backoff.Factor)
+		}
+		err = fn()
+		if !errors.Is(err, ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// GuaranteedUpdate gets the latest object under url, lets tryUpdate modify it and writes it back
+// at the version it was read, the whole read-modify-write is retried on conflict.
+// meta returns the ObjectMeta of the object to carry the resourceVersion.
+func GuaranteedUpdate[T any](url string, meta func(*T) *object.ObjectMeta, tryUpdate func(*T) error) (*T, error) {
+	var result *T
+	err := RetryOnConflict(DefaultRetry, func() error {
+		resList, err := Get(url)
+		if err != nil {
+			return err
+		}
+		if len(resList) == 0 {
+			return ErrNotFound
+		}
+		obj := new(T)
+		err = json.Unmarshal(resList[0].ValueBytes, obj)
+		if err != nil {
+			return err
+		}
+		err = tryUpdate(obj)
+		if err != nil {
+			return err
+		}
+		meta(obj).ResourceVersion = strconv.FormatInt(resList[0].ResourceVersion, 10)
+		err = Put(url, obj)
+		if err != nil {
+			return err
+		}
+		meta(obj).ResourceVersion = ""
+		result = obj
+		return nil
+	})
+	return result, err
+}
+
+func replicaSetMeta(rs *object.ReplicaSet) *object.ObjectMeta {
+	return &rs.ObjectMeta
+}
+
+// UpdateReplicas sets the replicas on the latest version of the rs under url,
+// the changes made to the rs by others in the meantime are kept
+func UpdateReplicas(url string, replicas int32) error {
+	_, err := GuaranteedUpdate(url, replicaSetMeta, func(rs *object.ReplicaSet) error {
+		rs.Spec.Replicas = replicas
+		return nil
+	})
+	return err
+}
+
+// UpdateConfigRS applies tryUpdate to the latest config of the rs and retries on conflict
+func (r RESTClient) UpdateConfigRS(namespace string, name string, tryUpdate func(rs *object.ReplicaSet) error) (*object.ReplicaSet, error) {
+	url := r.Base + config.NamespacedKey(config.RSConfigPrefix, namespace, name)
+	return GuaranteedUpdate(url, replicaSetMeta, tryUpdate)
+}
+
+// UpdateRuntimeRS applies tryUpdate to the latest runtime rs and retries on conflict
+func (r RESTClient) UpdateRuntimeRS(namespace string, name string, tryUpdate func(rs *object.ReplicaSet) error) (*object.ReplicaSet, error) {
+	url := r.Base + config.NamespacedKey(config.RSPrefix, namespace, name)
+	return GuaranteedUpdate(url, replicaSetMeta, tryUpdate)
+}
+
+// UpdateConfigPodWithRetry applies tryUpdate to the latest config of the pod and retries on conflict
+func (r RESTClient) UpdateConfigPodWithRetry(namespace string, name string, tryUpdate func(pod *object.Pod) error) (*object.Pod, error) {
+	url := r.Base + config.NamespacedKey(config.PodConfigPREFIX, namespace, name)
+	return GuaranteedUpdate(url, func(pod *object.Pod) *object.ObjectMeta {
+		return &pod.ObjectMeta
+	}, tryUpdate)
+}
+
+// UpdateNode applies tryUpdate to the latest node registered with the dynamicIp and retries on conflict
+func (r RESTClient) UpdateNode(dynamicIp string, tryUpdate func(node *object.Node) error) (*object.Node, error) {
+	url := r.Base + config.NODE_PREFIX + "/" + dynamicIp
+	return GuaranteedUpdate(url, func(node *object.Node) *object.ObjectMeta {
+		return &node.MetaData
+	}, tryUpdate)
+}
@@ -2,7 +2,6 @@ package autoscaler
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -11,12 +10,10 @@ import (
 	"minik8s/object"
 	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/klog"
-	"minik8s/pkg/listerwatcher"
 	concurrentmap "minik8s/util/map"
 	"minik8s/util/queue"
-	"path"
 	"sync"
 	"time"
 )
@@ -59,12 +56,12 @@ type stringAndChan struct {
 }
 
 type AutoscalerController struct {
-	ls                *listerwatcher.ListerWatcher
+	acInformer        *informer.Informer[object.Autoscaler]
+	dmInformer        *informer.Informer[object.Deployment]
+	rsInformer        *informer.Informer[object.ReplicaSet]
+	podInformer       *informer.Informer[object.Pod]
 	promClient        *client.PromClient
 	stopChannel       chan struct{}
-	resyncInterval    time.Duration
-	deploymentMap     *concurrentmap.ConcurrentMapTrait[string, object.VersionedDeployment]
-	replicasetMap     *concurrentmap.ConcurrentMapTrait[string, object.VersionedReplicaset]
 	autoscalerMap     *concurrentmap.ConcurrentMapTrait[string, object.VersionedAutoscaler]
 	lockMap           *concurrentmap.ConcurrentMapTrait[string, sync.Mutex]
 	object2autoscaler *concurrentmap.ConcurrentMapTrait[scalableObject, stringAndChan] // mapping an object key to the autoscaler key
@@ -74,12 +71,12 @@ type AutoscalerController struct {
 func NewAutoscalerController(controllerCtx util.ControllerContext) *AutoscalerController {
 	promBase := fmt.Sprintf("http://%s:%s", controllerCtx.MasterIP, controllerCtx.PromServerPort)
 	ac := &AutoscalerController{
-		ls:                controllerCtx.Ls,
+		acInformer:        controllerCtx.InformerFactory.Autoscalers(),
+		dmInformer:        controllerCtx.InformerFactory.Deployments(),
+		rsInformer:        controllerCtx.InformerFactory.ReplicaSets(),
+		podInformer:       controllerCtx.InformerFactory.Pods(),
 		promClient:        client.NewPromClient(promBase),
 		stopChannel:       make(chan struct{}),
-		resyncInterval:    time.Duration(controllerCtx.Config.ResyncIntervals) * time.Second,
-		deploymentMap:     concurrentmap.NewConcurrentMapTrait[string, object.VersionedDeployment](),
-		replicasetMap:     concurrentmap.NewConcurrentMapTrait[string, object.VersionedReplicaset](),
 		autoscalerMap:     concurrentmap.NewConcurrentMapTrait[string, object.VersionedAutoscaler](),
 		lockMap:           concurrentmap.NewConcurrentMapTrait[string, sync.Mutex](),
 		object2autoscaler: concurrentmap.NewConcurrentMapTrait[scalableObject, stringAndChan](),
@@ -89,95 +86,36 @@ func NewAutoscalerController(controllerCtx util.ControllerContext) *AutoscalerCo
 }
 
 func (acc *AutoscalerController) Run(ctx context.Context) {
-	acc.register()
+	// the existing autoscalers are told as added once registered, their targets must be cached by then
+	if informer.WaitForCacheSync(ctx.Done(), acc.dmInformer.HasSynced, acc.rsInformer.HasSynced, acc.podInformer.HasSynced, acc.acInformer.HasSynced) {
+		acc.register()
+	}
 	<-ctx.Done()
 	close(acc.stopChannel)
 }
 
 func (acc *AutoscalerController) register() {
-	registerWatchAutoscaler := func() {
-		for {
-			err := acc.ls.Watch("/registry/autoscaler/default", acc.watchAutoscaler, acc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/autoscaler : %s\n", err.Error())
-			}
-			time.Sleep(5 * time.Second)
-		}
-	}
-
-	registerWatchReplicaset := func() {
-		for {
-			err := acc.ls.Watch(config.RSConfigPrefix, acc.watchReplicaset, acc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/rsConfig : %s\n", err.Error())
-			}
-			time.Sleep(5 * time.Second)
-		}
-	}
-
-	registerWatchDeployment := func() {
-		for {
-			err := acc.ls.Watch("/registry/deployment/default", acc.watchDeployment, acc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/deployment : %s\n", err.Error())
-			}
-			time.Sleep(5 * time.Second)
-		}
-	}
-
-	//registerResyncLoop := func() {
-	//	for {
-	//		{
-	//			resList, err := acc.ls.List("/registry/autoscaler/default")
-	//			if err != nil {
-	//				klog.Errorf("Error synchronizing!\n")
-	//				goto failed
-	//			}
-	//			newMap := make(map[string]object.VersionedAutoscaler)
-	//			for _, res := range resList {
-	//				versionedAS := object.VersionedAutoscaler{Version: res.ResourceVersion}
-	//				_ = json.Unmarshal(res.ValueBytes, &versionedAS.Autoscaler)
-	//				newMap[res.Key] = versionedAS
-	//			}
-	//			acc.autoscalerMap.UpdateAll(newMap, object.SelectNewerAutoscaler)
-	//		}
-	//		{
-	//			resList, err := acc.ls.List(config.RSConfigPrefix)
-	//			if err != nil {
-	//				klog.Errorf("Error synchronizing!\n")
-	//				goto failed
-	//			}
-	//			newMap := make(map[string]object.VersionedReplicaset)
-	//			for _, res := range resList {
-	//				versionedRS := object.VersionedReplicaset{Version: res.ResourceVersion}
-	//				_ = json.Unmarshal(res.ValueBytes, &versionedRS.Replicaset)
-	//				newMap[res.Key] = versionedRS
-	//			}
-	//			acc.replicasetMap.UpdateAll(newMap, object.SelectNewerReplicaset)
-	//		}
-	//		{
-	//			resList, err := acc.ls.List("/registry/deployment/default")
-	//			if err != nil {
-	//				klog.Errorf("Error synchronizing!\n")
-	//				goto failed
-	//			}
-	//			newMap := make(map[string]object.VersionedDeployment)
-	//			for _, res := range resList {
-	//				versionedDM := object.VersionedDeployment{Version: res.ResourceVersion}
-	//				_ = json.Unmarshal(res.ValueBytes, &versionedDM.Deployment)
-	//				newMap[res.Key] = versionedDM
-	//			}
-	//			acc.deploymentMap.UpdateAll(newMap, object.SelectNewerDeployment)
-	//		}
-	//	failed:
-	//		time.Sleep(acc.resyncInterval)
-	//	}
-	//}
-	//go registerResyncLoop()
-
-	go registerWatchAutoscaler()
-	go registerWatchDeployment()
-	go registerWatchReplicaset()
+	acc.acInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Autoscaler]{
+		AddFunc: acc.addAutoscaler,
+		UpdateFunc: func(key string, oldAc *object.Autoscaler, newAc *object.Autoscaler) {
+			// stop monitoring with the old spec
+			acc.handleAutoscalerDel(key)
+			acc.addAutoscaler(key, newAc)
+		},
+		DeleteFunc: func(key string, ac *object.Autoscaler) {
+			acc.handleAutoscalerDel(key)
+		},
+	})
+	acc.dmInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Deployment]{
+		DeleteFunc: func(key string, dm *object.Deployment) {
+			acc.handleScalableObjectDel(scalableObject{kind: deployment, key: key})
+		},
+	})
+	acc.rsInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.ReplicaSet]{
+		DeleteFunc: func(key string, rs *object.ReplicaSet) {
+			acc.handleScalableObjectDel(scalableObject{kind: replicaset, key: key})
+		},
+	})
 }
 
 /*
@@ -187,79 +125,12 @@ Deleting an object.Autoscaler will not delete its deployments or replica-sets.
 
 Deleting an object.Deployment or object.ReplicaSet will make its owner autoscaler unavailable.
 */
-func (acc *AutoscalerController) watchAutoscaler(res etcdstore.WatchRes) {
-	switch res.ResType {
-	case etcdstore.PUT:
-		ac := object.Autoscaler{}
-		err := json.Unmarshal(res.ValueBytes, &ac)
-		if err != nil {
-			klog.Errorf("%s\n", err.Error())
-			return
-		}
-		vac := object.VersionedAutoscaler{
-			Version:    res.ResourceVersion,
-			Autoscaler: ac,
-		}
-		acc.handleAutoscalerPut(res.Key, vac)
-		break
-	case etcdstore.DELETE:
-		acc.handleAutoscalerDel(res.Key)
-		break
-	}
-}
-
-func (acc *AutoscalerController) watchDeployment(res etcdstore.WatchRes) {
-	switch res.ResType {
-	case etcdstore.PUT:
-		dm := object.Deployment{}
-		err := json.Unmarshal(res.ValueBytes, &dm)
-		if err != nil {
-			klog.Errorf("%s\n", err.Error())
-			return
-		}
-		vdm := object.VersionedDeployment{
-			Version:    res.ResourceVersion,
-			Deployment: dm,
-		}
-		acc.deploymentMap.Put(res.Key, vdm)
-		break
-	case etcdstore.DELETE:
-		scalableObj := scalableObject{
-			kind: deployment,
-			key:  res.Key,
-		}
-		acc.handleScalableObjectDel(scalableObj)
-		break
-	}
-}
-
-func (acc *AutoscalerController) watchReplicaset(res etcdstore.WatchRes) {
-	switch res.ResType {
-	case etcdstore.PUT:
-		rs := object.ReplicaSet{}
-		err := json.Unmarshal(res.ValueBytes, &rs)
-		if err != nil {
-			klog.Errorf("%s\n", err.Error())
-			return
-		}
-		if rs.Spec.Replicas == 0 {
-			acc.replicasetMap.Del(res.Key)
-		} else {
-			vrs := object.VersionedReplicaset{
-				Version:    res.ResourceVersion,
-				Replicaset: rs,
-			}
-			acc.replicasetMap.Put(res.Key, vrs)
-		}
-		break
-	case etcdstore.DELETE:
-		scalableObj := scalableObject{
-			kind: replicaset,
-			key:  res.Key,
-		}
-		acc.handleScalableObjectDel(scalableObj)
-		break
+func (acc *AutoscalerController) addAutoscaler(key string, ac *object.Autoscaler) {
+	vac := object.VersionedAutoscaler{
+		Version:    informer.ResourceVersion(&ac.Metadata),
+		Autoscaler: *ac,
 	}
+	acc.handleAutoscalerPut(key, vac)
 }
 
 func (acc *AutoscalerController) handleAutoscalerPut(autoscalerKey string, vac object.VersionedAutoscaler) {
@@ -293,7 +164,7 @@ func (acc *AutoscalerController) handleAutoscalerPut(autoscalerKey string, vac o
 	var monitoringLoop func(stopCh <-chan struct{}, deploymentKey string, calculateFuncMap map[string]metricHandler, maxReplicas int32, minReplicas int32, scaleInterval int32)
 	switch scalableObj.kind {
 	case deployment:
-		_, ok = acc.deploymentMap.Get(scalableObj.key)
+		_, ok = acc.dmInformer.Get(scalableObj.key)
 		if !ok {
 			return
 		} else {
@@ -301,8 +172,9 @@ func (acc *AutoscalerController) handleAutoscalerPut(autoscalerKey string, vac o
 		}
 		break
 	case replicaset:
-		_, ok = acc.replicasetMap.Get(scalableObj.key)
-		if !ok {
+		var rs *object.ReplicaSet
+		rs, ok = acc.rsInformer.Get(scalableObj.key)
+		if !ok || rs.Spec.Replicas == 0 {
 			fmt.Println("replicaset not found, return !")
 			return
 		} else {
@@ -333,7 +205,7 @@ func (acc *AutoscalerController) monitoringDeploymentLoop(stopCh <-chan struct{}
 			return
 		default:
 		}
-		vdm, ok := acc.deploymentMap.Get(deploymentKey)
+		dm, ok := acc.dmInformer.Get(deploymentKey)
 		if ok {
 			/*
 				The reason for using deployment here is that the replicaset which is controlled by a deployment
@@ -341,14 +213,11 @@ func (acc *AutoscalerController) monitoringDeploymentLoop(stopCh <-chan struct{}
 				And at a particular point in time, one deployment may have more than one replicaset.
 				They all have the same name but different keys.
 			*/
-			rs, rsExist := getControlledRS(vdm.Deployment, acc.replicasetMap.SnapShot())
+			rs, rsExist := acc.getControlledRS(*dm)
 			if !rsExist {
 				goto StepEnd
 			}
-			pods, err := client.GetRSPods(acc.ls, rs.ObjectMeta.Name, rs.UID)
-			if err != nil {
-				goto StepEnd
-			}
+			pods := informer.ListRSPods(acc.podInformer, &rs)
 			podStatusList := acc.getPodsResourcePercentage(pods)
 
 			var cpu, cpuBound cpuPercentage
@@ -365,11 +234,11 @@ func (acc *AutoscalerController) monitoringDeploymentLoop(stopCh <-chan struct{}
 				memoryBound = memoryPercentage(handler.bound)
 			}
 
-			rsKey := path.Join(config.RSConfigPrefix, rs.Name+rs.UID)
+			rsKey := config.NamespacedKey(config.RSConfigPrefix, rs.Namespace, rs.Name)
 			if (cpuMetric && cpu > cpuBound) || (memoryMetric && memory > memoryBound) {
 				rs.Spec.Replicas += 1
 				if rs.Spec.Replicas <= maxReplicas {
-					err = client.Put(acc.apiServerBase+rsKey, rs)
+					err := client.UpdateReplicas(acc.apiServerBase+rsKey, rs.Spec.Replicas)
 					if err != nil {
 						goto StepEnd
 					}
@@ -377,7 +246,7 @@ func (acc *AutoscalerController) monitoringDeploymentLoop(stopCh <-chan struct{}
 			} else if (cpuMetric && memoryMetric && cpu < cpuBound && memory < memoryBound) || (cpuMetric && !memoryMetric && cpu < cpuBound) || (memoryMetric && !cpuMetric && memory < memoryBound) {
 				rs.Spec.Replicas -= 1
 				if rs.Spec.Replicas >= minReplicas {
-					err = client.Put(acc.apiServerBase+rsKey, rs)
+					err := client.UpdateReplicas(acc.apiServerBase+rsKey, rs.Spec.Replicas)
 					if err != nil {
 						goto StepEnd
 					}
@@ -399,13 +268,10 @@ func (acc *AutoscalerController) monitoringReplicasetLoop(stopCh <-chan struct{}
 			return
 		default:
 		}
-		vrs, ok := acc.replicasetMap.Get(replicasetKey)
-		if ok {
-			pods, err := client.GetRSPods(acc.ls, vrs.Replicaset.ObjectMeta.Name, vrs.Replicaset.UID)
-			if err != nil {
-				fmt.Printf("cannot get pods of replicaset %s\n", vrs.Replicaset.ObjectMeta.Name)
-				goto StepEnd
-			}
+		cached, ok := acc.rsInformer.Get(replicasetKey)
+		if ok && cached.Spec.Replicas != 0 {
+			rs := *cached
+			pods := informer.ListRSPods(acc.podInformer, &rs)
 			podStatusList := acc.getPodsResourcePercentage(pods)
 			fmt.Println("pod's status information")
 			for _, status := range podStatusList {
@@ -454,21 +320,21 @@ func (acc *AutoscalerController) monitoringReplicasetLoop(stopCh <-chan struct{}
 					defer mtx.Unlock()
 					if (cpuMetric && cpu > cpuBound) || (memoryMetric && memory > memoryBound) {
 						fmt.Println("increase replicas")
-						for vrs.Replicaset.Spec.Replicas < maxReplicas {
-							vrs.Replicaset.Spec.Replicas += 1
-							err = client.Put(acc.apiServerBase+replicasetKey, vrs.Replicaset)
+						for rs.Spec.Replicas < maxReplicas {
+							rs.Spec.Replicas += 1
+							err := client.UpdateReplicas(acc.apiServerBase+replicasetKey, rs.Spec.Replicas)
 							if err != nil {
-								vrs.Replicaset.Spec.Replicas -= 1
+								rs.Spec.Replicas -= 1
 							}
 							time.Sleep(time.Duration(interval) * time.Second)
 						}
 					} else if (cpuMetric && memoryMetric && cpu < cpuBound && memory < memoryBound) || (cpuMetric && !memoryMetric && cpu < cpuBound) || (memoryMetric && !cpuMetric && memory < memoryBound) {
 						fmt.Println("decrease replicas")
-						for vrs.Replicaset.Spec.Replicas > minReplicas {
-							vrs.Replicaset.Spec.Replicas -= 1
-							err = client.Put(acc.apiServerBase+replicasetKey, vrs.Replicaset)
+						for rs.Spec.Replicas > minReplicas {
+							rs.Spec.Replicas -= 1
+							err := client.UpdateReplicas(acc.apiServerBase+replicasetKey, rs.Spec.Replicas)
 							if err != nil {
-								vrs.Replicaset.Spec.Replicas += 1
+								rs.Spec.Replicas += 1
 							}
 							time.Sleep(time.Duration(interval) * time.Second)
 						}
@@ -476,27 +342,28 @@ func (acc *AutoscalerController) monitoringReplicasetLoop(stopCh <-chan struct{}
 				}
 			}()
 		}
-	StepEnd:
 		time.Sleep(2 * time.Second)
 	}
 }
 
-func getControlledRS(deployment object.Deployment, rsMap map[string]object.VersionedReplicaset) (object.ReplicaSet, bool) {
-	versionedRS := object.VersionedReplicaset{
-		Version: 0,
-	}
-	for _, vrs := range rsMap {
-		for _, owner := range vrs.Replicaset.OwnerReferences {
-			if owner.UID == deployment.Metadata.UID && owner.Name == deployment.Metadata.Name && vrs.Version >= versionedRS.Version {
-				versionedRS = vrs
+// getControlledRS returns the latest cached replicaset owned by the deployment
+func (acc *AutoscalerController) getControlledRS(deployment object.Deployment) (object.ReplicaSet, bool) {
+	var controlled *object.ReplicaSet
+	for _, rs := range acc.rsInformer.ByIndex(informer.OwnerIndex, deployment.Metadata.UID) {
+		if rs.Namespace != deployment.Metadata.Namespace || rs.Spec.Replicas == 0 {
+			continue
+		}
+		for _, owner := range rs.OwnerReferences {
+			if owner.UID == deployment.Metadata.UID && owner.Name == deployment.Metadata.Name &&
+				(controlled == nil || informer.ResourceVersion(&rs.ObjectMeta) >= informer.ResourceVersion(&controlled.ObjectMeta)) {
+				controlled = rs
 			}
 		}
 	}
-	if versionedRS.Version == 0 {
-		return versionedRS.Replicaset, false
-	} else {
-		return versionedRS.Replicaset, true
+	if controlled == nil {
+		return object.ReplicaSet{}, false
 	}
+	return *controlled, true
 }
 
 func calculateMaxStatus(statusList []resourceStatus) (cpuPercentage, memoryPercentage) {
@@ -574,16 +441,6 @@ func (acc *AutoscalerController) handleAutoscalerDel(key string) {
 }
 
 func (acc *AutoscalerController) handleScalableObjectDel(scalableObj scalableObject) {
-	switch scalableObj.kind {
-	case replicaset:
-		acc.replicasetMap.Del(scalableObj.key)
-		break
-	case deployment:
-		acc.deploymentMap.Del(scalableObj.key)
-		break
-	default:
-		return
-	}
 	autoscalerMeta, ok := acc.object2autoscaler.Get(scalableObj)
 	if !ok {
 		return
@@ -596,16 +453,18 @@ func (acc *AutoscalerController) handleScalableObjectDel(scalableObj scalableObj
 func autoscaler2ScalableObject(vac object.VersionedAutoscaler) (scalableObject, error) {
 	targetObjKind := vac.Autoscaler.Spec.ScaleTargetRef.Kind
 	targetObjName := vac.Autoscaler.Spec.ScaleTargetRef.Name
+	// the target always lives in the same namespace as the autoscaler
+	namespace := vac.Autoscaler.Metadata.Namespace
 	scalableObj := scalableObject{}
 	key := ""
 	switch targetObjKind {
 	case app.Deployment:
-		key = "/registry/deployment/default/" + targetObjName
+		key = config.NamespacedKey(config.DeploymentPrefix, namespace, targetObjName)
 		scalableObj.key = key
 		scalableObj.kind = deployment
 		break
 	case app.Replicaset:
-		key = path.Join(config.RSConfigPrefix, targetObjName)
+		key = config.NamespacedKey(config.RSConfigPrefix, namespace, targetObjName)
 		scalableObj.key = key
 		scalableObj.kind = replicaset
 		break
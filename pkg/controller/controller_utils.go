@@ -15,5 +15,5 @@ func FilterActivePods(pods []*object.Pod) []*object.Pod {
 }
 
 func IsPodActive(p *object.Pod) bool {
-	return (object.PodExit != p.Status.Phase) && (object.Failed != p.Status.Phase)
+	return (object.PodExit != p.Status.Phase) && (object.Failed != p.Status.Phase) && (object.PodSucceeded != p.Status.Phase)
 }
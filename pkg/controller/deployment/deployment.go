@@ -2,18 +2,15 @@ package deployment
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"minik8s/cmd/kube-controller-manager/util"
 	"minik8s/object"
 	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/klog"
-	"minik8s/pkg/listerwatcher"
 	concurrentmap "minik8s/util/map"
-	"path"
 	"time"
 )
 
@@ -23,24 +20,22 @@ type RsPodStatus struct {
 }
 
 type DeploymentController struct {
-	ls             *listerwatcher.ListerWatcher
-	deploymentMap  *concurrentmap.ConcurrentMapTrait[string, object.VersionedDeployment]
-	replicasetMap  *concurrentmap.ConcurrentMapTrait[string, object.ReplicaSet]
-	dm2rs          *concurrentmap.ConcurrentMapTrait[string, string] // dm2rs mapping from Deployment key in etcdstore to Replicaset key in etcdstore
-	resyncInterval time.Duration
-	stopChannel    chan struct{}
-	apiServerBase  string
+	dmInformer    *informer.Informer[object.Deployment]
+	rsInformer    *informer.Informer[object.ReplicaSet]
+	replicasetMap *concurrentmap.ConcurrentMapTrait[string, object.ReplicaSet]
+	dm2rs         *concurrentmap.ConcurrentMapTrait[string, string] // dm2rs mapping from Deployment key in etcdstore to Replicaset key in etcdstore
+	stopChannel   chan struct{}
+	apiServerBase string
 }
 
 func NewDeploymentController(controllerCtx util.ControllerContext) *DeploymentController {
 	dc := &DeploymentController{
-		ls:             controllerCtx.Ls,
-		deploymentMap:  concurrentmap.NewConcurrentMapTrait[string, object.VersionedDeployment](),
-		replicasetMap:  concurrentmap.NewConcurrentMapTrait[string, object.ReplicaSet](),
-		dm2rs:          concurrentmap.NewConcurrentMapTrait[string, string](),
-		stopChannel:    make(chan struct{}),
-		resyncInterval: time.Duration(controllerCtx.Config.ResyncIntervals) * time.Second,
-		apiServerBase:  "http://" + controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort,
+		dmInformer:    controllerCtx.InformerFactory.Deployments(),
+		rsInformer:    controllerCtx.InformerFactory.ReplicaSets(),
+		replicasetMap: concurrentmap.NewConcurrentMapTrait[string, object.ReplicaSet](),
+		dm2rs:         concurrentmap.NewConcurrentMapTrait[string, string](),
+		stopChannel:   make(chan struct{}),
+		apiServerBase: "http://" + controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort,
 	}
 	if dc.apiServerBase == "" {
 		klog.Fatalf("uninitialized apiserver base!\n")
@@ -50,276 +45,213 @@ func NewDeploymentController(controllerCtx util.ControllerContext) *DeploymentCo
 
 func (dc *DeploymentController) Run(ctx context.Context) {
 	klog.Debugf("[DeploymentController] running...\n")
-	dc.register()
+	// the existing deployments are told as added once registered, they adopt their replicasets from the cache
+	if informer.WaitForCacheSync(ctx.Done(), dc.dmInformer.HasSynced, dc.rsInformer.HasSynced) {
+		dc.register()
+	}
 	<-ctx.Done()
 	close(dc.stopChannel)
 }
 
 func (dc *DeploymentController) register() {
-	//registerWatchReplicaset := func() {
-	//	for {
-	//		err := dc.ls.Watch(config.RSConfigPrefix, dc.watchReplicaset, dc.stopChannel)
-	//		if err != nil {
-	//			klog.Errorf("Error watching %s\n", config.RSConfigPrefix)
-	//		} else {
-	//			return
-	//		}
-	//		time.Sleep(5 * time.Second)
-	//	}
-	//}
+	dc.dmInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Deployment]{
+		AddFunc:    dc.addDeployment,
+		UpdateFunc: dc.updateDeployment,
+		DeleteFunc: dc.deleteDeployment,
+	})
+}
 
-	registerAddDeployment := func() {
-		for {
-			err := dc.ls.Watch("/registry/deployment/default", dc.putDeployment, dc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/deployment : %s\n", err.Error())
-			} else {
-				return
-			}
-			time.Sleep(5 * time.Second)
-		}
+func (dc *DeploymentController) addDeployment(key string, dm *object.Deployment) {
+	deployment := *dm
+	if rs, ok := dc.getControlledRS(deployment); ok {
+		// the deployment existed before the controller started
+		rsKey := config.NamespacedKey(config.RSConfigPrefix, rs.Namespace, rs.Name)
+		dc.dm2rs.Put(key, rsKey)
+		dc.replicasetMap.Put(rsKey, *rs)
+		return
 	}
+	rsUidNew := uuid.New().String()
+	rsNameNew := deployment.Metadata.Name + rsUidNew
+	rsKeyNew := config.NamespacedKey(config.RSConfigPrefix, deployment.Metadata.Namespace, rsNameNew)
+	rs := object.ReplicaSet{
+		ObjectMeta: object.ObjectMeta{
+			Name:      rsNameNew,
+			Namespace: deployment.Metadata.Namespace,
+			Labels:    deployment.Metadata.Labels,
+			UID:       rsUidNew,
+			OwnerReferences: []object.OwnerReference{{
+				Kind:       "Deployment",
+				Name:       deployment.Metadata.Name,
+				UID:        deployment.Metadata.UID,
+				Controller: false,
+			}},
+		},
+		Spec: object.ReplicaSetSpec{
+			Replicas: deployment.Spec.Replicas,
+			Template: deployment.Spec.Template,
+		},
+	}
+	dc.dm2rs.Put(key, rsKeyNew)
 
-	registerDeleteDeployment := func() {
-		for {
-			err := dc.ls.Watch("/registry/deployment/default", dc.deleteDeployment, dc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/deployment : %s\n", err.Error())
-			} else {
-				return
-			}
-			time.Sleep(5 * time.Second)
-		}
+	err := client.Put(dc.apiServerBase+rsKeyNew, rs)
+	if err != nil {
+		klog.Errorf("Error send new rs to etcd\n")
 	}
+	dc.replicasetMap.Put(rsKeyNew, rs)
+}
 
-	registerResyncLoop := func() {
-		for {
-			//{
-			//	resList, err := dc.ls.List(config.RSConfigPrefix)
-			//	if err != nil {
-			//		klog.Errorf("Error synchronizing!\n")
-			//		goto failed
-			//	}
-			//	newMap := make(map[string]object.VersionedReplicaset)
-			//	for _, res := range resList {
-			//		versionedRS := object.VersionedReplicaset{Version: res.ResourceVersion}
-			//		_ = json.Unmarshal(res.ValueBytes, &versionedRS.Replicaset)
-			//		newMap[res.Key] = versionedRS
-			//	}
-			//	dc.replicasetMap.UpdateAll(newMap, object.SelectNewerReplicaset)
-			//}
-			{
-				resList, err := dc.ls.List("/registry/deployment/default")
-				if err != nil {
-					klog.Errorf("Error synchronizing!\n")
-					goto failed
-				}
-				newMap := make(map[string]object.VersionedDeployment)
-				for _, res := range resList {
-					versionedDM := object.VersionedDeployment{Version: res.ResourceVersion}
-					_ = json.Unmarshal(res.ValueBytes, &versionedDM.Deployment)
-					newMap[res.Key] = versionedDM
-				}
-				dc.deploymentMap.UpdateAll(newMap, object.SelectNewerDeployment)
-			}
-		failed:
-			time.Sleep(dc.resyncInterval)
+// getControlledRS returns the latest cached replicaset owned by the deployment
+func (dc *DeploymentController) getControlledRS(deployment object.Deployment) (*object.ReplicaSet, bool) {
+	var controlled *object.ReplicaSet
+	for _, rs := range dc.rsInformer.ByIndex(informer.OwnerIndex, deployment.Metadata.UID) {
+		if rs.Namespace != deployment.Metadata.Namespace {
+			continue
+		}
+		if controlled == nil || informer.ResourceVersion(&rs.ObjectMeta) > informer.ResourceVersion(&controlled.ObjectMeta) {
+			controlled = rs
 		}
 	}
-
-	go registerResyncLoop()
-	go registerAddDeployment()
-	go registerDeleteDeployment()
+	return controlled, controlled != nil
 }
 
-func (dc *DeploymentController) putDeployment(res etcdstore.WatchRes) {
-	if res.ResType != etcdstore.PUT {
-		return
-	}
-	deployment := object.Deployment{}
-	err := json.Unmarshal(res.ValueBytes, &deployment)
-	if err != nil {
-		klog.Errorf("%s\n", err.Error())
-		return
-	}
-	if res.IsCreate {
+func (dc *DeploymentController) updateDeployment(key string, oldDm *object.Deployment, newDm *object.Deployment) {
+	deployment := *newDm
+	var err error
+	update := func() {
+		fmt.Println("go update")
 		rsUidNew := uuid.New().String()
 		rsNameNew := deployment.Metadata.Name + rsUidNew
-		rsKeyNew := path.Join(config.RSConfigPrefix, rsNameNew)
-		rs := object.ReplicaSet{
+		//rsUidOld := ""
+		rsNameOld := ""
+		rsKeyNew := config.NamespacedKey(config.RSConfigPrefix, deployment.Metadata.Namespace, rsNameNew)
+		rsKeyOld, _ := dc.dm2rs.Get(key)
+		fmt.Println(deployment)
+		surge := *deployment.Spec.Strategy.RollingUpdate.MaxSurge
+		replicas := deployment.Spec.Replicas
+		rsOld, isOldRSExist := dc.replicasetMap.Get(rsKeyOld)
+		var decreaseOldDone, increaseNewDone bool
+		increaseNewDone = false
+		if !isOldRSExist {
+			// old replicaset doesn't exist
+			decreaseOldDone = true
+		} else {
+			rsNameOld = rsOld.Name
+			decreaseOldDone = rsOld.Spec.Replicas <= 0
+		}
+
+		// clear old replicaset's owner
+		if isOldRSExist && !decreaseOldDone {
+			latest, err := client.GuaranteedUpdate(dc.apiServerBase+rsKeyOld, func(rs *object.ReplicaSet) *object.ObjectMeta {
+				return &rs.ObjectMeta
+			}, func(rs *object.ReplicaSet) error {
+				rs.OwnerReferences = []object.OwnerReference{}
+				return nil
+			})
+			if err != nil {
+				klog.Errorf("%s\n", err.Error())
+				rsOld.OwnerReferences = []object.OwnerReference{}
+			} else {
+				rsOld = *latest
+			}
+			dc.replicasetMap.Put(rsKeyOld, rsOld)
+		}
+
+		// create new replicaset and set its owner
+		rsNew := object.ReplicaSet{
 			ObjectMeta: object.ObjectMeta{
-				Name:   rsNameNew,
-				Labels: deployment.Metadata.Labels,
-				UID:    rsUidNew,
+				Name:      rsNameNew,
+				Namespace: deployment.Metadata.Namespace,
+				UID:       rsUidNew,
+				Labels:    deployment.Metadata.Labels,
 				OwnerReferences: []object.OwnerReference{{
 					Kind:       "Deployment",
 					Name:       deployment.Metadata.Name,
-					UID:        deployment.Metadata.UID,
+					UID:        "",
 					Controller: false,
 				}},
 			},
 			Spec: object.ReplicaSetSpec{
-				Replicas: deployment.Spec.Replicas,
+				Replicas: func() int32 {
+					if surge < replicas {
+						return surge
+					} else {
+						return replicas
+					}
+				}(),
 				Template: deployment.Spec.Template,
 			},
 		}
-		dc.dm2rs.Put(res.Key, rsKeyNew)
 
-		err = client.Put(dc.apiServerBase+rsKeyNew, rs)
-		if err != nil {
-			klog.Errorf("Error send new rs to etcd\n")
-		}
-		dc.replicasetMap.Put(rsKeyNew, rs)
-	} else if res.IsModify {
-		update := func() {
-			fmt.Println("go update")
-			rsUidNew := uuid.New().String()
-			rsNameNew := deployment.Metadata.Name + rsUidNew
-			//rsUidOld := ""
-			rsNameOld := ""
-			rsKeyNew := path.Join(config.RSConfigPrefix, rsNameNew)
-			rsKeyOld, _ := dc.dm2rs.Get(res.Key)
-			fmt.Println(deployment)
-			surge := *deployment.Spec.Strategy.RollingUpdate.MaxSurge
-			replicas := deployment.Spec.Replicas
-			rsOld, isOldRSExist := dc.replicasetMap.Get(rsKeyOld)
-			var decreaseOldDone, increaseNewDone bool
-			increaseNewDone = false
-			if !isOldRSExist {
-				// old replicaset doesn't exist
-				decreaseOldDone = true
-			} else {
-				rsNameOld = rsOld.Name
-				decreaseOldDone = rsOld.Spec.Replicas <= 0
-			}
+		// the new replicaset is created by the first put and only scaled later,
+		// its other fields may have been changed by the autoscaler in the meantime
+		rsNewCreated := false
+		for true {
+			fmt.Printf("[delta loop]\n")
+			fmt.Printf("[new rs] %s - %d\n", rsNameNew, rsNew.Spec.Replicas)
+			fmt.Printf("[old rs] %s - %d\n", rsNameOld, rsOld.Spec.Replicas)
 
-			// clear old replicaset's owner
-			if isOldRSExist && !decreaseOldDone {
-				rsOld.OwnerReferences = []object.OwnerReference{}
-				err = client.Put(dc.apiServerBase+rsKeyOld, rsOld)
+			if !increaseNewDone {
+				stash := rsNew.Spec.Replicas
+				fmt.Printf("[send new rs] %s - %d\n", rsNameNew, rsNew.Spec.Replicas)
+				if rsNewCreated {
+					err = client.UpdateReplicas(dc.apiServerBase+rsKeyNew, rsNew.Spec.Replicas)
+				} else {
+					err = client.Put(dc.apiServerBase+rsKeyNew, rsNew)
+				}
 				if err != nil {
-					klog.Errorf("%s\n", err.Error())
+					rsNew.Spec.Replicas = stash
+					fmt.Printf("[error] send new rs %s %s\n", rsNameNew, err.Error())
+					goto LoopErr
+				}
+				rsNewCreated = true
+				dc.replicasetMap.Put(rsKeyNew, rsNew)
+				rsNew.Spec.Replicas += 1
+				if rsNew.Spec.Replicas > replicas {
+					increaseNewDone = true
 				}
-				dc.replicasetMap.Put(rsKeyOld, rsOld)
-			}
-
-			// create new replicaset and set its owner
-			rsNew := object.ReplicaSet{
-				ObjectMeta: object.ObjectMeta{
-					Name:   rsNameNew,
-					UID:    rsUidNew,
-					Labels: deployment.Metadata.Labels,
-					OwnerReferences: []object.OwnerReference{{
-						Kind:       "Deployment",
-						Name:       deployment.Metadata.Name,
-						UID:        "",
-						Controller: false,
-					}},
-				},
-				Spec: object.ReplicaSetSpec{
-					Replicas: func() int32 {
-						if surge < replicas {
-							return surge
-						} else {
-							return replicas
-						}
-					}(),
-					Template: deployment.Spec.Template,
-				},
 			}
 
-			for true {
-				fmt.Printf("[delta loop]\n")
-				fmt.Printf("[new rs] %s - %d\n", rsNameNew, rsNew.Spec.Replicas)
-				fmt.Printf("[old rs] %s - %d\n", rsNameOld, rsOld.Spec.Replicas)
+			time.Sleep(7 * time.Second)
 
-				if !increaseNewDone {
-					stash := rsNew.Spec.Replicas
-					fmt.Printf("[send new rs] %s - %d\n", rsNameNew, rsNew.Spec.Replicas)
-					err = client.Put(dc.apiServerBase+rsKeyNew, rsNew)
+			if !decreaseOldDone {
+				stash := rsOld.Spec.Replicas
+				rsOld.Spec.Replicas -= 1
+				fmt.Printf("[send old rs] %s - %d\n", rsNameOld, rsOld.Spec.Replicas)
+				if rsOld.Spec.Replicas > 0 {
+					err = client.UpdateReplicas(dc.apiServerBase+rsKeyOld, rsOld.Spec.Replicas)
 					if err != nil {
-						rsNew.Spec.Replicas = stash
-						fmt.Printf("[error] send new rs %s %s\n", rsNameNew, err.Error())
+						fmt.Printf("[error] send old rs %s %s\n", rsNameOld, err.Error())
+						rsOld.Spec.Replicas = stash
 						goto LoopErr
 					}
-					dc.replicasetMap.Put(rsKeyNew, rsNew)
-					rsNew.Spec.Replicas += 1
-					if rsNew.Spec.Replicas > replicas {
-						increaseNewDone = true
-					}
-				}
-
-				time.Sleep(7 * time.Second)
-
-				if !decreaseOldDone {
-					stash := rsOld.Spec.Replicas
-					rsOld.Spec.Replicas -= 1
-					fmt.Printf("[send old rs] %s - %d\n", rsNameOld, rsOld.Spec.Replicas)
-					if rsOld.Spec.Replicas > 0 {
-						err = client.Put(dc.apiServerBase+rsKeyOld, rsOld)
-						if err != nil {
-							fmt.Printf("[error] send old rs %s %s\n", rsNameOld, err.Error())
-							rsOld.Spec.Replicas = stash
-							goto LoopErr
-						}
-						dc.replicasetMap.Put(rsKeyOld, rsOld)
-					} else if rsOld.Spec.Replicas == 0 {
-						err = client.Del(dc.apiServerBase + rsKeyOld)
-						if err != nil {
-							fmt.Printf("[error] send old rs %s %s\n", rsNameOld, err.Error())
-							rsOld.Spec.Replicas = stash
-							goto LoopErr
-						}
-						decreaseOldDone = true
+					dc.replicasetMap.Put(rsKeyOld, rsOld)
+				} else if rsOld.Spec.Replicas == 0 {
+					err = client.Del(dc.apiServerBase + rsKeyOld)
+					if err != nil {
+						fmt.Printf("[error] send old rs %s %s\n", rsNameOld, err.Error())
+						rsOld.Spec.Replicas = stash
+						goto LoopErr
 					}
+					decreaseOldDone = true
 				}
+			}
 
-				if decreaseOldDone && increaseNewDone {
-					fmt.Printf("[old rs decreased] %s\n", rsNameOld)
-					fmt.Printf("[new rs increased] %s\n", rsNameNew)
-					break
-				}
-			LoopErr:
-				time.Sleep(7 * time.Second)
+			if decreaseOldDone && increaseNewDone {
+				fmt.Printf("[old rs decreased] %s\n", rsNameOld)
+				fmt.Printf("[new rs increased] %s\n", rsNameNew)
+				break
 			}
-			dc.dm2rs.Put(res.Key, rsKeyNew)
+		LoopErr:
+			time.Sleep(7 * time.Second)
 		}
-		go update()
+		dc.dm2rs.Put(key, rsKeyNew)
 	}
+	go update()
 }
 
-//func (dc *DeploymentController) watchReplicaset(res etcdstore.WatchRes) {
-//	switch res.ResType {
-//	case etcdstore.PUT:
-//		rs := object.ReplicaSet{}
-//		err := json.Unmarshal(res.ValueBytes, &rs)
-//		if err != nil {
-//			klog.Errorf("%s\n", err.Error())
-//			return
-//		}
-//		if rs.Spec.Replicas == 0 {
-//			dc.replicasetMap.Del(res.Key)
-//		} else {
-//			vrs := object.VersionedReplicaset{
-//				Version:    res.ResourceVersion,
-//				Replicaset: rs,
-//			}
-//			dc.replicasetMap.Put(res.Key, vrs)
-//		}
-//		break
-//	case etcdstore.DELETE:
-//		dc.replicasetMap.Del(res.Key)
-//		break
-//	}
-//}
-
-func (dc *DeploymentController) deleteDeployment(res etcdstore.WatchRes) {
-	if res.ResType != etcdstore.DELETE {
-		return
-	}
-	rsKey, _ := dc.dm2rs.Get(res.Key)
-	dc.deploymentMap.Del(res.Key)
-	dc.dm2rs.Del(res.Key)
+func (dc *DeploymentController) deleteDeployment(key string, dm *object.Deployment) {
+	rsKey, _ := dc.dm2rs.Get(key)
+	dc.dm2rs.Del(key)
 	err := client.Del(dc.apiServerBase + rsKey)
 	if err != nil {
 		klog.Errorf("Error del rs %s. Err : %s\n", dc.apiServerBase+rsKey, err.Error())
@@ -2,25 +2,20 @@ package jobcontroller
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"minik8s/cmd/kube-controller-manager/util"
 	"minik8s/object"
 	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/klog"
-	"minik8s/pkg/listerwatcher"
-	concurrentmap "minik8s/util/map"
 	"path"
 	"time"
 )
 
 type JobController struct {
-	ls            *listerwatcher.ListerWatcher
-	jobMap        *concurrentmap.ConcurrentMapTrait[string, object.VersionedGPUJob]
-	jobStatusMap  *concurrentmap.ConcurrentMapTrait[string, object.VersionedJobStatus]
+	jobInformer   *informer.Informer[object.GPUJob]
 	apiServerBase string
 	stopChannel   chan struct{}
 	allocator     *object.AccountAllocator
@@ -28,10 +23,8 @@ type JobController struct {
 
 func NewJobController(controllerCtx util.ControllerContext) *JobController {
 	jc := &JobController{
-		ls:            controllerCtx.Ls,
+		jobInformer:   controllerCtx.InformerFactory.Jobs(),
 		stopChannel:   make(chan struct{}),
-		jobMap:        concurrentmap.NewConcurrentMapTrait[string, object.VersionedGPUJob](),
-		jobStatusMap:  concurrentmap.NewConcurrentMapTrait[string, object.VersionedJobStatus](),
 		apiServerBase: "http://" + controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort,
 		allocator:     object.NewAccountAllocator(),
 	}
@@ -49,45 +42,29 @@ func (jc *JobController) Run(ctx context.Context) {
 }
 
 func (jc *JobController) register() {
-	registerPutJob := func() {
-		for {
-			err := jc.ls.Watch("/registry/job/default", jc.putJob, jc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/job\n")
-			} else {
+	jc.jobInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.GPUJob]{
+		AddFunc: func(key string, job *object.GPUJob) {
+			// the jobs existing before the controller started may already have their pod
+			if jc.hasPod(key) {
 				return
 			}
-			time.Sleep(5 * time.Second)
-		}
-	}
-
-	registerDelJob := func() {
-		for {
-			err := jc.ls.Watch("/registry/job/default", jc.delJob, jc.stopChannel)
-			if err != nil {
-				klog.Errorf("Error watching /registry/job\n")
-			} else {
-				return
-			}
-			time.Sleep(5 * time.Second)
-		}
-	}
+			jc.putJob(key, job)
+		},
+		UpdateFunc: func(key string, oldJob *object.GPUJob, newJob *object.GPUJob) {
+			jc.putJob(key, newJob)
+		},
+		DeleteFunc: jc.delJob,
+	})
+}
 
-	go registerPutJob()
-	go registerDelJob()
+func (jc *JobController) hasPod(key string) bool {
+	resList, err := client.Get(jc.apiServerBase + path.Join(config.Job2PodPrefix, path.Base(key)))
+	return err == nil && len(resList) > 0
 }
 
-func (jc *JobController) putJob(res etcdstore.WatchRes) {
-	if res.ResType != etcdstore.PUT {
-		return
-	}
+func (jc *JobController) putJob(key string, cached *object.GPUJob) {
 	// TODO
-	job := object.GPUJob{}
-	err := json.Unmarshal(res.ValueBytes, &job)
-	if err != nil {
-		klog.Errorf("%s\n", err.Error())
-		return
-	}
+	job := *cached
 	account, err := jc.allocator.Allocate(job.Spec.SlurmConfig.Partition)
 	if err != nil {
 		klog.Errorf("%s\n", err.Error())
@@ -95,16 +72,17 @@ func (jc *JobController) putJob(res etcdstore.WatchRes) {
 	}
 	pod := object.PodTemplate{
 		ObjectMeta: object.ObjectMeta{
-			Name:   fmt.Sprintf("Job-%s-Pod", job.Metadata.UID),
-			Labels: map[string]string{"kind": "gpu"},
-			UID:    uuid.New().String(),
+			Name:      fmt.Sprintf("Job-%s-Pod", job.Metadata.UID),
+			Namespace: job.Metadata.Namespace,
+			Labels:    map[string]string{"kind": "gpu"},
+			UID:       uuid.New().String(),
 		},
 		Spec: object.PodSpec{
 			Volumes: []object.Volume{
 				{
 					Name: "gpuPath",
 					Type: "hostPath",
-					Path: path.Join(config.SharedDataDirectory, path.Base(res.Key)),
+					Path: path.Join(config.SharedDataDirectory, path.Base(key)),
 				},
 			},
 			Containers: []object.Container{
@@ -118,7 +96,7 @@ func (jc *JobController) putJob(res etcdstore.WatchRes) {
 						account.GetPassword(),
 						account.GetHost(),
 						"/home/job",
-						path.Join(account.GetRemoteBasePath(), path.Base(res.Key)),
+						path.Join(account.GetRemoteBasePath(), path.Base(key)),
 					},
 					VolumeMounts: []object.VolumeMount{
 						{
@@ -136,21 +114,18 @@ func (jc *JobController) putJob(res etcdstore.WatchRes) {
 	}
 	go func() {
 		time.Sleep(time.Second * 3)
-		err = client.Put(jc.apiServerBase+config.PodConfigPREFIX+"/"+pod.Name, pod)
+		err = client.Put(jc.apiServerBase+config.NamespacedKey(config.PodConfigPREFIX, pod.Namespace, pod.Name), pod)
 		if err != nil {
 			klog.Errorf("Put job pod config error : %s\n", err.Error())
 			return
 		}
-		err = client.Put(jc.apiServerBase+path.Join(config.Job2PodPrefix, path.Base(res.Key)), object.Job2Pod{PodName: pod.Name})
+		err = client.Put(jc.apiServerBase+path.Join(config.Job2PodPrefix, path.Base(key)), object.Job2Pod{PodName: pod.Name, Namespace: pod.Namespace})
 		if err != nil {
 			klog.Errorf("Put Job2Pod error : %s\n", err.Error())
 		}
 	}()
 }
 
-func (jc *JobController) delJob(res etcdstore.WatchRes) {
-	if res.ResType != etcdstore.DELETE {
-		return
-	}
+func (jc *JobController) delJob(key string, job *object.GPUJob) {
 	// TODO
 }
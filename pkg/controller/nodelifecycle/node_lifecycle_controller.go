@@ -0,0 +1,196 @@
+package nodelifecycle
+
+import (
+	"context"
+	"fmt"
+	"minik8s/cmd/kube-controller-manager/util"
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/client"
+	"minik8s/pkg/controller"
+	"minik8s/pkg/informer"
+	"minik8s/pkg/klog"
+	"time"
+)
+
+// NodeLifecycleController watches the heartbeats of the nodes. A node which stopped renewing its lease
+// for the grace period is marked NotReady and tainted unreachable, then the pods which do not tolerate
+// the taint are evicted so that their replicasets create them again on the other nodes.
+type NodeLifecycleController struct {
+	nodeInformer  *informer.Informer[object.Node]
+	leaseInformer *informer.Informer[object.Lease]
+	podInformer   *informer.Informer[object.Pod]
+
+	monitorPeriod time.Duration
+	gracePeriod   time.Duration
+	// probeTimestamps are when the nodes without a lease were first seen, they get the grace period from then
+	probeTimestamps map[string]time.Time
+
+	Client client.RESTClient
+}
+
+func NewNodeLifecycleController(controllerCtx util.ControllerContext) *NodeLifecycleController {
+	restClient := client.RESTClient{
+		Base: "http://" + controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort,
+	}
+	options := controllerCtx.Config.NodeLifecycleControllerOptions
+	return &NodeLifecycleController{
+		nodeInformer:    controllerCtx.InformerFactory.Nodes(),
+		leaseInformer:   controllerCtx.InformerFactory.Leases(),
+		podInformer:     controllerCtx.InformerFactory.Pods(),
+		monitorPeriod:   time.Duration(options.NodeMonitorPeriod) * time.Second,
+		gracePeriod:     time.Duration(options.NodeMonitorGracePeriod) * time.Second,
+		probeTimestamps: make(map[string]time.Time),
+		Client:          restClient,
+	}
+}
+
+// Run checks the nodes every monitorPeriod until ctx is done
+func (nc *NodeLifecycleController) Run(ctx context.Context) {
+	klog.Debugf("[NodeLifecycleController]start running\n")
+	if !informer.WaitForCacheSync(ctx.Done(), nc.nodeInformer.HasSynced, nc.leaseInformer.HasSynced, nc.podInformer.HasSynced) {
+		return
+	}
+	ticker := time.NewTicker(nc.monitorPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			nc.monitorNodeHealth(now)
+			nc.evictPods(now)
+		}
+	}
+}
+
+// monitorNodeHealth marks the nodes without a recent heartbeat NotReady and taints them,
+// the taint is removed once the kubelet reports the node ready again
+func (nc *NodeLifecycleController) monitorNodeHealth(now time.Time) {
+	seen := make(map[string]time.Time)
+	for _, node := range nc.nodeInformer.List() {
+		dynamicIp := node.Spec.DynamicIp
+		lastHeartbeat, ok := nc.lastHeartbeat(dynamicIp)
+		if !ok {
+			if lastHeartbeat, ok = nc.probeTimestamps[dynamicIp]; !ok {
+				lastHeartbeat = now
+			}
+			seen[dynamicIp] = lastHeartbeat
+		}
+		tainted := hasUnreachableTaint(node)
+		if now.Sub(lastHeartbeat) > nc.gracePeriod {
+			condition := node.GetCondition(object.NodeReady)
+			if tainted && condition != nil && condition.Status == object.ConditionUnknown {
+				continue
+			}
+			klog.Warnf("node %s stopped posting heartbeats since %s\n", node.MetaData.Name, lastHeartbeat.Format(time.RFC3339))
+			nc.updateNode(dynamicIp, func(node *object.Node) {
+				markUnreachable(node, lastHeartbeat, now)
+			})
+		} else if tainted && node.IsReady() {
+			klog.Infof("node %s is ready again\n", node.MetaData.Name)
+			nc.updateNode(dynamicIp, func(node *object.Node) {
+				node.Spec.Taints = removeUnreachableTaint(node.Spec.Taints)
+			})
+		}
+	}
+	nc.probeTimestamps = seen
+}
+
+// lastHeartbeat returns when the lease of the node was renewed for the last time
+func (nc *NodeLifecycleController) lastHeartbeat(dynamicIp string) (time.Time, bool) {
+	lease, ok := nc.leaseInformer.Get(config.LEASE_PREFIX + "/" + dynamicIp)
+	if !ok {
+		return time.Time{}, false
+	}
+	return lease.Spec.RenewTime, true
+}
+
+func (nc *NodeLifecycleController) updateNode(dynamicIp string, update func(node *object.Node)) {
+	_, err := nc.Client.UpdateNode(dynamicIp, func(node *object.Node) error {
+		update(node)
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("update node %s fail: %s\n", dynamicIp, err.Error())
+	}
+}
+
+// evictPods evicts the pods of the tainted nodes once they do not tolerate the taints any more
+func (nc *NodeLifecycleController) evictPods(now time.Time) {
+	nodes := make(map[string]*object.Node)
+	for _, node := range nc.nodeInformer.List() {
+		if hasUnreachableTaint(node) {
+			nodes[node.MetaData.Name] = node
+		}
+	}
+	if len(nodes) == 0 {
+		return
+	}
+	for _, pod := range nc.podInformer.List() {
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok || !controller.IsPodActive(pod) {
+			continue
+		}
+		evictAt, evict := object.EvictionTime(pod.Spec.Tolerations, node.Spec.Taints, now)
+		if !evict || now.Before(evictAt) {
+			continue
+		}
+		nc.evictPod(pod, node)
+	}
+}
+
+// evictPod deletes the config of the pod and fails the runtime pod in place of the kubelet which is gone,
+// the kubelet deletes them both if it comes back
+func (nc *NodeLifecycleController) evictPod(cached *object.Pod, node *object.Node) {
+	klog.Infof("evict pod %s from node %s\n", config.NamespacedName(cached.Namespace, cached.Name), node.MetaData.Name)
+	err := nc.Client.DeleteConfigPod(cached.Namespace, cached.Name)
+	if err != nil {
+		klog.Warnf("delete config of pod %s fail: %s\n", cached.Name, err.Error())
+	}
+	pod := *cached
+	pod.Status.Phase = object.Failed
+	pod.Status.Reason = object.PodReasonNodeLost
+	pod.Status.Err = fmt.Sprintf("node %s which was running the pod is unresponsive", node.MetaData.Name)
+	err = nc.Client.UpdateRuntimePod(&pod)
+	if err != nil {
+		klog.Errorf("fail pod %s fail: %s\n", cached.Name, err.Error())
+	}
+}
+
+func markUnreachable(node *object.Node, lastHeartbeat time.Time, now time.Time) {
+	node.SetCondition(object.NodeCondition{
+		Type:              object.NodeReady,
+		Status:            object.ConditionUnknown,
+		LastHeartbeatTime: lastHeartbeat,
+		Reason:            "NodeStatusUnknown",
+		Message:           "Kubelet stopped posting node status.",
+	}, now)
+	if !hasUnreachableTaint(node) {
+		node.Spec.Taints = append(node.Spec.Taints, object.Taint{
+			Key:       object.TaintNodeUnreachable,
+			Effect:    object.TaintEffectNoExecute,
+			TimeAdded: &now,
+		})
+	}
+}
+
+func hasUnreachableTaint(node *object.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == object.TaintNodeUnreachable && taint.Effect == object.TaintEffectNoExecute {
+			return true
+		}
+	}
+	return false
+}
+
+func removeUnreachableTaint(taints []object.Taint) []object.Taint {
+	var kept []object.Taint
+	for _, taint := range taints {
+		if taint.Key != object.TaintNodeUnreachable {
+			kept = append(kept, taint)
+		}
+	}
+	return kept
+}
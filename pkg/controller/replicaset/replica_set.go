@@ -2,29 +2,28 @@ package replicaset
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"minik8s/cmd/kube-controller-manager/util"
 	"minik8s/object"
 	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
 	"minik8s/pkg/controller"
-	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/klog"
-	"minik8s/pkg/listerwatcher"
-	concurrent_map "minik8s/util/map"
 	"minik8s/util/queue"
-	"sync"
-	"time"
 )
 
+// maxRetries is the number of times a rs is retried before it is dropped,
+// it is synced again on the next event of the rs or its pods
+const maxRetries = 15
+
 type ReplicaSetController struct {
-	ls          *listerwatcher.ListerWatcher
+	rsInformer  *informer.Informer[object.ReplicaSet]
+	podInformer *informer.Informer[object.Pod]
 	stopChannel <-chan struct{}
 
-	// working queue
-	queue queue.ConcurrentQueue
-	cp    *concurrent_map.ConcurrentMap
+	// working queue of the keys of rs configs
+	queue *queue.RateLimitingQueue[string]
 
 	Client client.RESTClient
 }
@@ -34,12 +33,11 @@ func NewReplicaSetController(controllerCtx util.ControllerContext) *ReplicaSetCo
 		Base: "http://" + controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort,
 	}
 
-	cp := concurrent_map.NewConcurrentMap()
-
 	rsc := &ReplicaSetController{
-		ls:     controllerCtx.Ls,
-		cp:     cp,
-		Client: restClient,
+		rsInformer:  controllerCtx.InformerFactory.ReplicaSets(),
+		podInformer: controllerCtx.InformerFactory.Pods(),
+		queue:       queue.NewRateLimitingQueue[string]("replicaset", maxRetries),
+		Client:      restClient,
 	}
 	return rsc
 }
@@ -47,83 +45,65 @@ func NewReplicaSetController(controllerCtx util.ControllerContext) *ReplicaSetCo
 // Run begins watching and syncing.
 func (rsc *ReplicaSetController) Run(ctx context.Context) {
 	klog.Debugf("[ReplicaSetController]start running\n")
-	go rsc.register()
+	rsc.register()
+	// the pods of a rs are counted from the cache, syncing before it is filled would create them twice
+	if !informer.WaitForCacheSync(ctx.Done(), rsc.rsInformer.HasSynced, rsc.podInformer.HasSynced) {
+		return
+	}
 	go rsc.worker(ctx)
-	select {}
+	<-ctx.Done()
+	rsc.queue.ShutDown()
 }
 
 func (rsc *ReplicaSetController) register() {
-	watchAdd := func(rsc *ReplicaSetController) {
-		err := rsc.ls.Watch(config.RSConfigPrefix, rsc.addRS, rsc.stopChannel)
-		if err != nil {
-			fmt.Printf("[ReplicaSetController] ListWatch init fail...")
-		}
-	}
-
-	watchPod := func(rsc *ReplicaSetController) {
-		err := rsc.ls.Watch("/registry/pod/default", rsc.podOperation, rsc.stopChannel)
-		if err != nil {
-			fmt.Printf("[ReplicaSetController] ListWatch init fail...")
-		}
-	}
-
+	rsc.rsInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.ReplicaSet]{
+		AddFunc: rsc.addRS,
+		UpdateFunc: func(key string, oldRS *object.ReplicaSet, newRS *object.ReplicaSet) {
+			rsc.addRS(key, newRS)
+		},
+	})
+	rsc.podInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Pod]{
+		AddFunc: rsc.podOperation,
+		UpdateFunc: func(key string, oldPod *object.Pod, newPod *object.Pod) {
+			rsc.podOperation(key, newPod)
+		},
+	})
 	klog.Debugf("success register\n")
-
-	go watchAdd(rsc)
-	go watchPod(rsc)
-
 }
 
 // worker runs a worker thread that just dequeues items, processes them, and marks them done.
 func (rsc *ReplicaSetController) worker(ctx context.Context) {
-	var m sync.Mutex
-	for {
-		if !rsc.queue.Empty() {
-			key := rsc.queue.Front()
-			rsc.queue.Dequeue()
-			m.Lock()
-			rsc.syncReplicaSet(ctx, key.(string))
-			m.Unlock()
-		} else {
-			time.Sleep(time.Second)
-		}
+	for rsc.processNextWorkItem(ctx) {
 	}
 }
 
-func (rsc *ReplicaSetController) addRS(res etcdstore.WatchRes) {
-	// do not react to delete, delete is mocked by put
-	if res.ResType == etcdstore.DELETE {
-		return
+func (rsc *ReplicaSetController) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := rsc.queue.Get()
+	if shutdown {
+		return false
 	}
-	rs := &object.ReplicaSet{}
-	err := json.Unmarshal(res.ValueBytes, rs)
-	if err != nil {
-		fmt.Printf("addRS bad message\n")
-		return
+	defer rsc.queue.Done(key)
+
+	err := rsc.syncReplicaSet(ctx, key)
+	if err == nil {
+		rsc.queue.Forget(key)
+		return true
+	}
+	if rsc.queue.AddRateLimited(key) {
+		klog.Warnf("Error syncing replicaset %s, retrying : %s\n", key, err.Error())
+	} else {
+		klog.Errorf("Dropping replicaset %s out of the queue : %s\n", key, err.Error())
 	}
+	return true
+}
 
+func (rsc *ReplicaSetController) addRS(key string, rs *object.ReplicaSet) {
 	fmt.Printf("[addRS] message receive...\n")
-
-	// encode object to key
-	key := getKey(rs)
-	rsc.cp.Put(key, rs)
 	// enqueue key
-	rsc.queue.Enqueue(key)
+	rsc.queue.Add(key)
 }
 
-func (rsc *ReplicaSetController) podOperation(res etcdstore.WatchRes) {
-	if res.ResType == etcdstore.DELETE {
-		return
-	}
-	// check ownership
-	pod := &object.Pod{}
-	fmt.Printf("[podOperation] messgae:%v\n", len(res.ValueBytes))
-	err := json.Unmarshal(res.ValueBytes, pod)
-	if err != nil {
-		fmt.Printf("[podOperation] bad message,unmarshal fail\n")
-		return
-	}
-
+func (rsc *ReplicaSetController) podOperation(key string, pod *object.Pod) {
 	if pod.Status.Phase == "" {
 		return
 	}
@@ -132,23 +112,23 @@ func (rsc *ReplicaSetController) podOperation(res etcdstore.WatchRes) {
 
 	isOwned, name, _ := client.OwnByRs(pod)
 	if isOwned {
-		rs, err := client.GetRuntimeRS(rsc.ls, name)
-		//fmt.Printf("[podOperation] rs:%v owns:%v\n", rs.NodeName, pod.NodeName)
-		if err == nil {
-			// encode object to key
-			key := getKey(rs)
-			rsc.cp.Put(key, rs)
+		// the rs always lives in the namespace of its pods
+		rsKey := config.NamespacedKey(config.RSConfigPrefix, pod.Namespace, name)
+		if _, ok := rsc.rsInformer.Get(rsKey); ok {
 			// enqueue key
-			rsc.queue.Enqueue(key)
+			rsc.queue.Add(rsKey)
 		}
 	}
 }
 
 func (rsc *ReplicaSetController) syncReplicaSet(ctx context.Context, key string) error {
 	// get expected replica set
-	rs, _ := rsc.cp.Get(key).(*object.ReplicaSet)
+	rs, ok := rsc.rsInformer.Get(key)
+	if !ok {
+		return nil
+	}
 	// get all actual pods of the rs
-	allPods, _ := client.GetRSPods(rsc.ls, rs.Name, rs.UID)
+	allPods := informer.ListRSPods(rsc.podInformer, rs)
 	// filter all inactive pods
 	activePods := controller.FilterActivePods(allPods)
 	fmt.Printf("[syncReplicaSet] active pods of rs %v:%v\n", rs.Name, len(activePods))
@@ -156,11 +136,14 @@ func (rsc *ReplicaSetController) syncReplicaSet(ctx context.Context, key string)
 		return nil
 	}
 	// manage pods
-	err := rsc.manageReplicas(ctx, activePods, rs)
+	manageErr := rsc.manageReplicas(ctx, activePods, rs)
 	// calculate new status
 	newStatus := calculateStatus(rs, activePods)
 	// update status
-	err = putReplicaSet(ctx, &rsc.Client, rs, newStatus)
+	err := putReplicaSet(ctx, &rsc.Client, rs, newStatus)
+	if manageErr != nil {
+		return manageErr
+	}
 	return err
 }
 
@@ -183,6 +166,7 @@ func (rsc *ReplicaSetController) manageReplicas(ctx context.Context, filteredPod
 			err := rsc.Client.CreateRSPod(ctx, rs)
 			if err != nil {
 				klog.Errorf("create pod fail\n")
+				return err
 			}
 		}
 
@@ -192,9 +176,10 @@ func (rsc *ReplicaSetController) manageReplicas(ctx context.Context, filteredPod
 		fmt.Printf("[manageReplicas] del pods number:%v\n", len(podsToDelete))
 
 		for _, pod := range podsToDelete {
-			err := rsc.Client.DeleteConfigPod(pod.Name)
+			err := rsc.Client.DeleteConfigPod(pod.Namespace, pod.Name)
 			if err != nil {
 				klog.Errorf("delete pod config fail Name:%s uid:%s\n", pod.Name, pod.UID)
+				return err
 			}
 		}
 	}
@@ -213,19 +198,19 @@ func getPodsToDelete(filteredPods []*object.Pod, diff int) []*object.Pod {
 	return filteredPods[:diff]
 }
 
-func getKey(rs *object.ReplicaSet) string {
-	return rs.Name + rs.UID
-}
-
-func putReplicaSet(ctx context.Context, c *client.RESTClient, rs *object.ReplicaSet, newStatus object.ReplicaSetStatus) error {
+func putReplicaSet(ctx context.Context, c *client.RESTClient, cached *object.ReplicaSet, newStatus object.ReplicaSetStatus) error {
+	// the cached rs is shared with the other controllers, work on a copy
+	rs := *cached
 	rs.Status = newStatus
+	// the runtime rs is another key, the version of the config does not apply to it
+	rs.ResourceVersion = ""
 	var err error
 
 	if rs.Spec.Replicas == 0 {
 		// do real deletion
-		err = c.DeleteRS(rs.Name)
+		err = c.DeleteRS(rs.Namespace, rs.Name)
 	} else {
-		err = c.PutWrap("/registry/rs/default/"+rs.Name, rs)
+		err = c.PutWrap(config.NamespacedKey(config.RSPrefix, rs.Namespace, rs.Name), &rs)
 	}
 
 	return err
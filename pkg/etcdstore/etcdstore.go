@@ -2,7 +2,9 @@ package etcdstore
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	etcd "go.etcd.io/etcd/client/v3"
 	"minik8s/pkg/klog"
 	"time"
@@ -11,6 +13,14 @@ import (
 type Store struct {
 	client *etcd.Client
 }
+
+// ErrConflict is returned by Update when the key has been modified after the expected revision
+var ErrConflict = errors.New("resource version conflict")
+
+// ErrCompacted is returned by WatchFrom when the revision to resume from has been compacted,
+// the events since then are lost and the caller has to list again
+var ErrCompacted = errors.New("resource version too old, relist")
+
 type WatchResType int
 
 const (
@@ -89,6 +99,23 @@ func (s *Store) Put(key string, val []byte) error {
 	return err
 }
 
+// Update is a compare-and-swap version of Put, val is written only if the mod revision of key
+// still equals expectedModRevision. An expectedModRevision of 0 means that key must not exist.
+func (s *Store) Update(key string, val []byte, expectedModRevision int64) error {
+	kv := etcd.NewKV(s.client)
+	response, err := kv.Txn(context.TODO()).
+		If(etcd.Compare(etcd.ModRevision(key), "=", expectedModRevision)).
+		Then(etcd.OpPut(key, string(val))).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !response.Succeeded {
+		return ErrConflict
+	}
+	return nil
+}
+
 func (s *Store) Del(key string) error {
 	kv := etcd.NewKV(s.client)
 	_, err := kv.Delete(context.TODO(), key)
@@ -172,10 +199,17 @@ func (s *Store) PrefixWatch(key string) (context.CancelFunc, <-chan WatchRes) {
 }
 
 func (s *Store) PrefixGet(key string) ([]ListRes, error) {
+	ret, _, err := s.PrefixGetWithRevision(key)
+	return ret, err
+}
+
+// PrefixGetWithRevision is PrefixGet which also returns the revision of the store the list was read at,
+// watching from that revision misses no event after the list
+func (s *Store) PrefixGetWithRevision(key string) ([]ListRes, int64, error) {
 	kv := etcd.NewKV(s.client)
 	response, err := kv.Get(context.TODO(), key, etcd.WithPrefix())
 	if err != nil {
-		return []ListRes{}, err
+		return []ListRes{}, 0, err
 	}
 	var ret []ListRes
 	for _, kv := range response.Kvs {
@@ -187,5 +221,54 @@ func (s *Store) PrefixGet(key string) ([]ListRes, error) {
 		}
 		ret = append(ret, res)
 	}
-	return ret, nil
+	return ret, response.Header.Revision, nil
+}
+
+// WatchFrom is Watch (or PrefixWatch if withPrefix) resumed right after resourceVersion,
+// the events since then are replayed before the new ones.
+func (s *Store) WatchFrom(key string, resourceVersion int64, withPrefix bool) (context.CancelFunc, <-chan WatchRes, error) {
+	opts := []etcd.OpOption{etcd.WithRev(resourceVersion + 1)}
+	if withPrefix {
+		opts = append(opts, etcd.WithPrefix())
+	}
+	// a watch on a compacted revision is only canceled asynchronously, check it first
+	kv := etcd.NewKV(s.client)
+	_, err := kv.Get(context.TODO(), key, append(opts, etcd.WithCountOnly())...)
+	if errors.Is(err, rpctypes.ErrCompacted) {
+		return nil, nil, ErrCompacted
+	}
+	if err != nil && !errors.Is(err, rpctypes.ErrFutureRev) {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	watchResChan := make(chan WatchRes)
+	watch := func(c chan<- WatchRes) {
+		watchChan := s.client.Watch(ctx, key, opts...)
+		for watchResponse := range watchChan {
+			if watchResponse.CompactRevision != 0 {
+				klog.Errorf("Watching key %s from %d: %s\n", key, resourceVersion, ErrCompacted.Error())
+			}
+			for _, event := range watchResponse.Events {
+				res := WatchRes{
+					ResourceVersion: event.Kv.ModRevision,
+					CreateVersion:   event.Kv.CreateRevision,
+					IsCreate:        event.IsCreate(),
+					IsModify:        event.IsModify(),
+					Key:             string(event.Kv.Key),
+					ValueBytes:      event.Kv.Value,
+				}
+				if event.Type == etcd.EventTypeDelete {
+					res.ResType = DELETE
+				} else {
+					res.ResType = PUT
+				}
+				c <- res
+			}
+		}
+		klog.Infof("Closing resumed watching channel for key %s\n", key)
+		close(c)
+	}
+	go watch(watchResChan)
+	return cancel, watchResChan, nil
 }
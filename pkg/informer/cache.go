@@ -0,0 +1,129 @@
+package informer
+
+import (
+	mapset "github.com/deckarep/golang-set/v2"
+	"sync"
+)
+
+// IndexFunc returns the values an object is indexed by
+type IndexFunc[T any] func(obj *T) []string
+
+// cache is a thread safe map from etcd keys to objects, with indices from index values to keys
+type cache[T any] struct {
+	items    map[string]*T
+	indexers map[string]IndexFunc[T]
+	// indices maps the name of an index to the keys of the objects per index value
+	indices map[string]map[string]mapset.Set[string]
+	mtx     sync.RWMutex
+}
+
+func newCache[T any]() *cache[T] {
+	return &cache[T]{
+		items:    make(map[string]*T),
+		indexers: make(map[string]IndexFunc[T]),
+		indices:  make(map[string]map[string]mapset.Set[string]),
+	}
+}
+
+func (c *cache[T]) get(key string) (*T, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	obj, ok := c.items[key]
+	return obj, ok
+}
+
+func (c *cache[T]) list() []*T {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	ret := make([]*T, 0, len(c.items))
+	for _, obj := range c.items {
+		ret = append(ret, obj)
+	}
+	return ret
+}
+
+func (c *cache[T]) keys() []string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	ret := make([]string, 0, len(c.items))
+	for key := range c.items {
+		ret = append(ret, key)
+	}
+	return ret
+}
+
+func (c *cache[T]) put(key string, obj *T) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if old, ok := c.items[key]; ok {
+		c.unindex(key, old)
+	}
+	c.items[key] = obj
+	c.index(key, obj)
+}
+
+func (c *cache[T]) del(key string) (*T, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	old, ok := c.items[key]
+	if ok {
+		c.unindex(key, old)
+		delete(c.items, key)
+	}
+	return old, ok
+}
+
+func (c *cache[T]) byIndex(name string, value string) []*T {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	var ret []*T
+	keys, ok := c.indices[name][value]
+	if !ok {
+		return ret
+	}
+	for _, key := range keys.ToSlice() {
+		ret = append(ret, c.items[key])
+	}
+	return ret
+}
+
+// addIndexer registers f under name and indexes the objects already in the cache with it
+func (c *cache[T]) addIndexer(name string, f IndexFunc[T]) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.indexers[name] = f
+	c.indices[name] = make(map[string]mapset.Set[string])
+	for key, obj := range c.items {
+		c.indexOne(name, f, key, obj)
+	}
+}
+
+func (c *cache[T]) index(key string, obj *T) {
+	for name, f := range c.indexers {
+		c.indexOne(name, f, key, obj)
+	}
+}
+
+func (c *cache[T]) indexOne(name string, f IndexFunc[T], key string, obj *T) {
+	index := c.indices[name]
+	for _, value := range f(obj) {
+		if index[value] == nil {
+			index[value] = mapset.NewThreadUnsafeSet[string]()
+		}
+		index[value].Add(key)
+	}
+}
+
+func (c *cache[T]) unindex(key string, obj *T) {
+	for name, f := range c.indexers {
+		index := c.indices[name]
+		for _, value := range f(obj) {
+			if keys, ok := index[value]; ok {
+				keys.Remove(key)
+				if keys.Cardinality() == 0 {
+					delete(index, value)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package informer
+
+import (
+	"gotest.tools/v3/assert"
+	"minik8s/object"
+	"testing"
+)
+
+func TestCacheIndex(t *testing.T) {
+	c := newCache[object.Pod]()
+	c.addIndexer(NamespaceIndex, func(pod *object.Pod) []string {
+		return []string{pod.Namespace}
+	})
+	c.put("a", &object.Pod{ObjectMeta: object.ObjectMeta{Name: "a", Namespace: "ns1"}})
+	c.put("b", &object.Pod{ObjectMeta: object.ObjectMeta{Name: "b", Namespace: "ns1"}})
+	assert.Equal(t, 2, len(c.byIndex(NamespaceIndex, "ns1")))
+
+	c.put("b", &object.Pod{ObjectMeta: object.ObjectMeta{Name: "b", Namespace: "ns2"}})
+	assert.Equal(t, 1, len(c.byIndex(NamespaceIndex, "ns1")))
+	assert.Equal(t, "b", c.byIndex(NamespaceIndex, "ns2")[0].Name)
+
+	c.del("a")
+	assert.Equal(t, 0, len(c.byIndex(NamespaceIndex, "ns1")))
+	assert.Equal(t, 1, len(c.list()))
+}
@@ -0,0 +1,121 @@
+package informer
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/listerwatcher"
+	"sync"
+	"time"
+)
+
+type runner interface {
+	Run(stopCh <-chan struct{})
+}
+
+// SharedInformerFactory hands out one informer per prefix, so that the controllers
+// sharing it list and watch every resource only once
+type SharedInformerFactory struct {
+	ls           *listerwatcher.ListerWatcher
+	resyncPeriod time.Duration
+	informers    map[string]runner
+	started      map[string]bool
+	mtx          sync.Mutex
+}
+
+func NewSharedInformerFactory(ls *listerwatcher.ListerWatcher, resyncPeriod time.Duration) *SharedInformerFactory {
+	return &SharedInformerFactory{
+		ls:           ls,
+		resyncPeriod: resyncPeriod,
+		informers:    make(map[string]runner),
+		started:      make(map[string]bool),
+	}
+}
+
+// Start runs the informers which have not been started yet, call it again after asking for new informers
+func (f *SharedInformerFactory) Start(stopCh <-chan struct{}) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for prefix, inf := range f.informers {
+		if !f.started[prefix] {
+			go inf.Run(stopCh)
+			f.started[prefix] = true
+		}
+	}
+}
+
+// informerFor returns the informer of prefix, it is created on the first call
+func informerFor[T any](f *SharedInformerFactory, prefix string, meta MetaFunc[T]) *Informer[T] {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if inf, ok := f.informers[prefix]; ok {
+		return inf.(*Informer[T])
+	}
+	inf := NewInformer[T](f.ls, prefix, meta, f.resyncPeriod)
+	f.informers[prefix] = inf
+	return inf
+}
+
+// Pods informs of the runtime pods of every namespace
+func (f *SharedInformerFactory) Pods() *Informer[object.Pod] {
+	return informerFor(f, config.PodRuntimePrefix, func(pod *object.Pod) *object.ObjectMeta {
+		return &pod.ObjectMeta
+	})
+}
+
+// ConfigPods informs of the pod configs of every namespace, the scheduler assigns them to nodes
+func (f *SharedInformerFactory) ConfigPods() *Informer[object.Pod] {
+	return informerFor(f, config.PodConfigPREFIX, func(pod *object.Pod) *object.ObjectMeta {
+		return &pod.ObjectMeta
+	})
+}
+
+// ReplicaSets informs of the replicaset configs of every namespace
+func (f *SharedInformerFactory) ReplicaSets() *Informer[object.ReplicaSet] {
+	return informerFor(f, config.RSConfigPrefix, func(rs *object.ReplicaSet) *object.ObjectMeta {
+		return &rs.ObjectMeta
+	})
+}
+
+func (f *SharedInformerFactory) Deployments() *Informer[object.Deployment] {
+	return informerFor(f, config.DeploymentPrefix, func(dm *object.Deployment) *object.ObjectMeta {
+		return &dm.Metadata
+	})
+}
+
+func (f *SharedInformerFactory) Autoscalers() *Informer[object.Autoscaler] {
+	return informerFor(f, config.AutoscalerPrefix, func(ac *object.Autoscaler) *object.ObjectMeta {
+		return &ac.Metadata
+	})
+}
+
+func (f *SharedInformerFactory) Jobs() *Informer[object.GPUJob] {
+	return informerFor(f, config.JobPrefix, func(job *object.GPUJob) *object.ObjectMeta {
+		return &job.Metadata
+	})
+}
+
+// Services informs of the service configs of every namespace
+func (f *SharedInformerFactory) Services() *Informer[object.Service] {
+	return informerFor(f, config.ServiceConfigPrefix, func(service *object.Service) *object.ObjectMeta {
+		return &service.MetaData
+	})
+}
+
+func (f *SharedInformerFactory) DnsAndTrans() *Informer[object.DnsAndTrans] {
+	return informerFor(f, config.DnsAndTransPrefix, func(trans *object.DnsAndTrans) *object.ObjectMeta {
+		return &trans.MetaData
+	})
+}
+
+func (f *SharedInformerFactory) Nodes() *Informer[object.Node] {
+	return informerFor(f, config.NODE_PREFIX, func(node *object.Node) *object.ObjectMeta {
+		return &node.MetaData
+	})
+}
+
+// Leases informs of the heartbeats of the nodes, they are keyed by the dynamicIp of the node as the nodes are
+func (f *SharedInformerFactory) Leases() *Informer[object.Lease] {
+	return informerFor(f, config.LEASE_PREFIX, func(lease *object.Lease) *object.ObjectMeta {
+		return &lease.ObjectMeta
+	})
+}
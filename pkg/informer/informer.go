@@ -0,0 +1,294 @@
+package informer
+
+import (
+	"encoding/json"
+	"errors"
+	"go.uber.org/atomic"
+	"minik8s/object"
+	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/klog"
+	"minik8s/pkg/listerwatcher"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// NamespaceIndex indexes objects by their namespace
+	NamespaceIndex = "namespace"
+	// LabelIndex indexes objects by each of their labels, use LabelIndexValue to look them up
+	LabelIndex = "label"
+	// OwnerIndex indexes objects by the UID of each of their owners
+	OwnerIndex = "owner"
+)
+
+const retryInterval = 5 * time.Second
+
+// MetaFunc returns the metadata of an object
+type MetaFunc[T any] func(obj *T) *object.ObjectMeta
+
+// ResourceEventHandlerFuncs are called with the etcd key of the object, the objects must not be modified.
+// Any of the funcs can be nil.
+type ResourceEventHandlerFuncs[T any] struct {
+	AddFunc    func(key string, obj *T)
+	UpdateFunc func(key string, oldObj *T, newObj *T)
+	DeleteFunc func(key string, obj *T)
+	// ResyncFunc is called for every cached object once per resync period, the objects have not changed
+	ResyncFunc func(key string, obj *T)
+}
+
+// Informer keeps a local cache of every object under a prefix up to date by listing and watching it,
+// and tells the event handlers about the changes.
+//
+// The cached objects carry the ResourceVersion they were read at, so writing them back is a compare-and-swap.
+type Informer[T any] struct {
+	ls           *listerwatcher.ListerWatcher
+	prefix       string
+	meta         MetaFunc[T]
+	resyncPeriod time.Duration
+	cache        *cache[T]
+	handlers     []ResourceEventHandlerFuncs[T]
+	version      int64 // version is the latest version handled, the watch resumes from it
+	synced       *atomic.Bool
+	mtx          sync.Mutex // mtx serializes handling the events and registering the handlers
+}
+
+// NewInformer returns an informer of the objects under prefix, resyncPeriod 0 disables resync.
+// Use SharedInformerFactory to share one informer between controllers.
+func NewInformer[T any](ls *listerwatcher.ListerWatcher, prefix string, meta MetaFunc[T], resyncPeriod time.Duration) *Informer[T] {
+	inf := &Informer[T]{
+		ls:           ls,
+		prefix:       prefix,
+		meta:         meta,
+		resyncPeriod: resyncPeriod,
+		cache:        newCache[T](),
+		synced:       atomic.NewBool(false),
+	}
+	inf.cache.addIndexer(NamespaceIndex, func(obj *T) []string {
+		return []string{meta(obj).Namespace}
+	})
+	inf.cache.addIndexer(LabelIndex, func(obj *T) []string {
+		var values []string
+		for k, v := range meta(obj).Labels {
+			values = append(values, LabelIndexValue(k, v))
+		}
+		return values
+	})
+	inf.cache.addIndexer(OwnerIndex, func(obj *T) []string {
+		var values []string
+		for _, owner := range meta(obj).OwnerReferences {
+			values = append(values, owner.UID)
+		}
+		return values
+	})
+	return inf
+}
+
+// LabelIndexValue is the value of LabelIndex for the label key=value
+func LabelIndexValue(key string, value string) string {
+	return key + "=" + value
+}
+
+// AddEventHandler registers handler, it is told about the objects already cached as AddFunc
+func (inf *Informer[T]) AddEventHandler(handler ResourceEventHandlerFuncs[T]) {
+	inf.mtx.Lock()
+	defer inf.mtx.Unlock()
+	inf.handlers = append(inf.handlers, handler)
+	if handler.AddFunc == nil || !inf.synced.Load() {
+		return
+	}
+	for _, key := range inf.cache.keys() {
+		if obj, ok := inf.cache.get(key); ok {
+			handler.AddFunc(key, obj)
+		}
+	}
+}
+
+// AddIndexer registers a custom index, look the objects up with ByIndex(name, value)
+func (inf *Informer[T]) AddIndexer(name string, f IndexFunc[T]) {
+	inf.cache.addIndexer(name, f)
+}
+
+func (inf *Informer[T]) Get(key string) (*T, bool) {
+	return inf.cache.get(key)
+}
+
+func (inf *Informer[T]) List() []*T {
+	return inf.cache.list()
+}
+
+// Keys returns the etcd keys of the cached objects
+func (inf *Informer[T]) Keys() []string {
+	return inf.cache.keys()
+}
+
+func (inf *Informer[T]) ByIndex(name string, value string) []*T {
+	return inf.cache.byIndex(name, value)
+}
+
+// HasSynced is true once the first list has been cached
+func (inf *Informer[T]) HasSynced() bool {
+	return inf.synced.Load()
+}
+
+// Run lists and watches until stopCh is closed. The watch is resumed from the latest version handled,
+// a relist only happens if that version is too old.
+func (inf *Informer[T]) Run(stopCh <-chan struct{}) {
+	if inf.resyncPeriod > 0 {
+		go inf.resyncLoop(stopCh)
+	}
+	for {
+		if err := inf.relist(); err != nil {
+			klog.Errorf("Error listing %s : %s\n", inf.prefix, err.Error())
+			if !sleep(stopCh, retryInterval) {
+				return
+			}
+			continue
+		}
+		for {
+			err := inf.ls.WatchFrom(inf.prefix, inf.latestVersion(), inf.handle, stopCh)
+			if err == nil {
+				return
+			}
+			if errors.Is(err, listerwatcher.ErrResourceVersionTooOld) {
+				klog.Warnf("Relisting %s : %s\n", inf.prefix, err.Error())
+				break
+			}
+			klog.Errorf("Error watching %s : %s\n", inf.prefix, err.Error())
+			if !sleep(stopCh, retryInterval) {
+				return
+			}
+		}
+	}
+}
+
+// relist replaces the cache with a new list, the differences are handled as events
+func (inf *Informer[T]) relist() error {
+	resList, version, err := inf.ls.ListWithVersion(inf.prefix)
+	if err != nil {
+		return err
+	}
+	inf.mtx.Lock()
+	defer inf.mtx.Unlock()
+	listed := make(map[string]bool)
+	for _, res := range resList {
+		listed[res.Key] = true
+		inf.put(res.Key, res.ResourceVersion, res.ValueBytes)
+	}
+	for _, key := range inf.cache.keys() {
+		if !listed[key] {
+			inf.del(key)
+		}
+	}
+	inf.version = version
+	inf.synced.Store(true)
+	return nil
+}
+
+func (inf *Informer[T]) handle(res etcdstore.WatchRes) {
+	inf.mtx.Lock()
+	defer inf.mtx.Unlock()
+	switch res.ResType {
+	case etcdstore.PUT:
+		inf.put(res.Key, res.ResourceVersion, res.ValueBytes)
+	case etcdstore.DELETE:
+		inf.del(res.Key)
+	}
+	if res.ResourceVersion > inf.version {
+		inf.version = res.ResourceVersion
+	}
+}
+
+// put caches the object unless it is not newer than the cached one, which happens when events are replayed
+func (inf *Informer[T]) put(key string, version int64, value []byte) {
+	obj := new(T)
+	if err := json.Unmarshal(value, obj); err != nil {
+		klog.Errorf("Error unmarshalling %s : %s\n", key, err.Error())
+		return
+	}
+	inf.meta(obj).ResourceVersion = strconv.FormatInt(version, 10)
+	old, ok := inf.cache.get(key)
+	if ok && inf.versionOf(old) >= version {
+		return
+	}
+	inf.cache.put(key, obj)
+	for _, handler := range inf.handlers {
+		if ok && handler.UpdateFunc != nil {
+			handler.UpdateFunc(key, old, obj)
+		} else if !ok && handler.AddFunc != nil {
+			handler.AddFunc(key, obj)
+		}
+	}
+}
+
+func (inf *Informer[T]) del(key string) {
+	old, ok := inf.cache.del(key)
+	if !ok {
+		return
+	}
+	for _, handler := range inf.handlers {
+		if handler.DeleteFunc != nil {
+			handler.DeleteFunc(key, old)
+		}
+	}
+}
+
+func (inf *Informer[T]) resyncLoop(stopCh <-chan struct{}) {
+	for sleep(stopCh, inf.resyncPeriod) {
+		inf.mtx.Lock()
+		for _, key := range inf.cache.keys() {
+			obj, ok := inf.cache.get(key)
+			if !ok {
+				continue
+			}
+			for _, handler := range inf.handlers {
+				if handler.ResyncFunc != nil {
+					handler.ResyncFunc(key, obj)
+				}
+			}
+		}
+		inf.mtx.Unlock()
+	}
+}
+
+func (inf *Informer[T]) latestVersion() int64 {
+	inf.mtx.Lock()
+	defer inf.mtx.Unlock()
+	return inf.version
+}
+
+func (inf *Informer[T]) versionOf(obj *T) int64 {
+	return ResourceVersion(inf.meta(obj))
+}
+
+// ResourceVersion returns the version a cached object was read at, a larger one is newer
+func ResourceVersion(meta *object.ObjectMeta) int64 {
+	version, _ := strconv.ParseInt(meta.ResourceVersion, 10, 64)
+	return version
+}
+
+// WaitForCacheSync waits until every cacheSynced returns true, it returns false if stopCh is closed before
+func WaitForCacheSync(stopCh <-chan struct{}, cacheSynced ...func() bool) bool {
+	for {
+		synced := true
+		for _, f := range cacheSynced {
+			synced = synced && f()
+		}
+		if synced {
+			return true
+		}
+		if !sleep(stopCh, 100*time.Millisecond) {
+			return false
+		}
+	}
+}
+
+// sleep returns false if stopCh is closed before d passes
+func sleep(stopCh <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-stopCh:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
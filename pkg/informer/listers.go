@@ -0,0 +1,20 @@
+package informer
+
+import "minik8s/object"
+
+// ListRSPods returns the cached pods owned by the rs, they always live in the namespace of the rs
+func ListRSPods(pods *Informer[object.Pod], rs *object.ReplicaSet) []*object.Pod {
+	var result []*object.Pod
+	for _, pod := range pods.ByIndex(OwnerIndex, rs.UID) {
+		if pod.Namespace != rs.Namespace {
+			continue
+		}
+		for _, owner := range pod.OwnerReferences {
+			if owner.Name == rs.Name && owner.UID == rs.UID {
+				result = append(result, pod)
+				break
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,288 @@
+package containerRuntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"minik8s/object"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeContainer is a container of the FakeRuntime
+type FakeContainer struct {
+	Status    ContainerStatus
+	SandboxId string
+	// Config is nil for a sandbox
+	Config *object.Container
+	Logs   []FakeLogLine
+}
+
+// FakeLogLine is a line a FakeContainer wrote
+type FakeLogLine struct {
+	Time time.Time
+	Text string
+}
+
+// FakeRuntime keeps the containers in memory, so that the pods can be tested without docker
+type FakeRuntime struct {
+	lock       sync.Mutex
+	Images     map[string]bool
+	Containers map[string]*FakeContainer
+	// ExecFunc answers ExecInContainer, the commands succeed without output when it is nil
+	ExecFunc func(containerId string, cmd []string) (int, string, error)
+	nextId   int
+}
+
+func NewFakeRuntime() *FakeRuntime {
+	return &FakeRuntime{
+		Images:     make(map[string]bool),
+		Containers: make(map[string]*FakeContainer),
+	}
+}
+
+// find returns the container with the id or the name, it is called with the lock held
+func (f *FakeRuntime) find(containerId string) (*FakeContainer, error) {
+	if c, ok := f.Containers[containerId]; ok {
+		return c, nil
+	}
+	for _, c := range f.Containers {
+		if c.Status.Name == containerId {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no such container: %s", containerId)
+}
+
+func (f *FakeRuntime) create(name string, sandboxId string, config *object.Container) (string, error) {
+	if _, err := f.find(name); err == nil {
+		return "", fmt.Errorf("container name %s is already in use", name)
+	}
+	f.nextId++
+	id := "fake-" + strconv.Itoa(f.nextId)
+	f.Containers[id] = &FakeContainer{
+		Status:    ContainerStatus{Id: id, Name: name, State: ContainerCreated},
+		SandboxId: sandboxId,
+		Config:    config,
+	}
+	return id, nil
+}
+
+func (f *FakeRuntime) PullImages(images []string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, image := range images {
+		f.Images[image] = true
+	}
+	return nil
+}
+
+func (f *FakeRuntime) CreateSandbox(name string, ports []object.Port) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.create(name, "", nil)
+}
+
+func (f *FakeRuntime) CreateContainer(sandboxId string, container *object.Container) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, ok := f.Containers[sandboxId]; !ok {
+		return "", fmt.Errorf("no such sandbox: %s", sandboxId)
+	}
+	if !f.Images[container.Image] {
+		return "", fmt.Errorf("no such image: %s", container.Image)
+	}
+	return f.create(container.Name, sandboxId, container)
+}
+
+func (f *FakeRuntime) StartContainer(containerId string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return err
+	}
+	c.Status.State = ContainerRunning
+	c.Status.ExitCode = 0
+	c.Status.StartedAt = time.Now()
+	c.Status.FinishedAt = time.Time{}
+	if c.Config == nil {
+		c.Status.IPAddress = "10.10.0." + strings.TrimPrefix(c.Status.Id, "fake-")
+	}
+	return nil
+}
+
+func (f *FakeRuntime) StopContainer(containerId string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return err
+	}
+	if c.Status.State == ContainerRunning {
+		f.exit(c, 137)
+	}
+	return nil
+}
+
+func (f *FakeRuntime) exit(c *FakeContainer, exitCode int) {
+	c.Status.State = ContainerExited
+	c.Status.ExitCode = exitCode
+	c.Status.FinishedAt = time.Now()
+	c.Status.IPAddress = ""
+}
+
+// Exit makes a running container exit with exitCode, as if its process ended
+func (f *FakeRuntime) Exit(containerId string, exitCode int) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return err
+	}
+	if c.Status.State != ContainerRunning {
+		return fmt.Errorf("container %s is not running", containerId)
+	}
+	f.exit(c, exitCode)
+	return nil
+}
+
+func (f *FakeRuntime) RemoveContainer(containerId string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return err
+	}
+	delete(f.Containers, c.Status.Id)
+	return nil
+}
+
+func (f *FakeRuntime) InspectContainer(containerId string) (*ContainerStatus, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return nil, err
+	}
+	status := c.Status
+	return &status, nil
+}
+
+// Container returns a copy of the container with the id or the name
+func (f *FakeRuntime) Container(containerId string) (FakeContainer, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return FakeContainer{}, false
+	}
+	return *c, true
+}
+
+// Log makes the container write a line now
+func (f *FakeRuntime) Log(containerId string, text string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return err
+	}
+	c.Logs = append(c.Logs, FakeLogLine{Time: time.Now(), Text: text})
+	return nil
+}
+
+// ContainerLogs returns the lines written so far, it does not follow them
+func (f *FakeRuntime) ContainerLogs(ctx context.Context, containerId string, options LogOptions) (io.ReadCloser, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range c.Logs {
+		if (!options.Since.IsZero() && line.Time.Before(options.Since)) || (!options.Until.IsZero() && line.Time.After(options.Until)) {
+			continue
+		}
+		text := line.Text + "\n"
+		if options.Timestamps {
+			text = line.Time.Format(time.RFC3339Nano) + " " + text
+		}
+		lines = append(lines, text)
+	}
+	if options.TailLines != nil && int64(len(lines)) > *options.TailLines {
+		lines = lines[int64(len(lines))-*options.TailLines:]
+	}
+	return ioutil.NopCloser(strings.NewReader(strings.Join(lines, ""))), nil
+}
+
+func (f *FakeRuntime) running(containerId string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	c, err := f.find(containerId)
+	if err != nil {
+		return err
+	}
+	if c.Status.State != ContainerRunning {
+		return errors.New("container " + containerId + " is not running")
+	}
+	return nil
+}
+
+func (f *FakeRuntime) ExecInContainer(ctx context.Context, containerId string, cmd []string) (int, string, error) {
+	if err := f.running(containerId); err != nil {
+		return 0, "", err
+	}
+	if f.ExecFunc == nil {
+		return 0, "", nil
+	}
+	return f.ExecFunc(containerId, cmd)
+}
+
+// Exec writes the output of ExecFunc to stdout, or it copies stdin to stdout as cat does if ExecFunc is nil
+func (f *FakeRuntime) Exec(ctx context.Context, containerId string, options ExecOptions) (int, error) {
+	if err := f.running(containerId); err != nil {
+		return 0, err
+	}
+	if f.ExecFunc != nil {
+		exitCode, output, err := f.ExecFunc(containerId, options.Cmd)
+		if err != nil {
+			return 0, err
+		}
+		_, err = io.WriteString(options.Stdout, output)
+		return exitCode, err
+	}
+	if options.Stdin != nil {
+		if _, err := io.Copy(options.Stdout, options.Stdin); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+// PortForward connects to the port on the host, the fake sandboxes have no network of their own
+func (f *FakeRuntime) PortForward(ctx context.Context, sandboxId string, port int32, stream io.ReadWriteCloser) error {
+	if err := f.running(sandboxId); err != nil {
+		return err
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
+	}
+	return CopyStreams(ctx, conn, stream)
+}
+
+func (f *FakeRuntime) ContainerStats(containerId string) (*ContainerStats, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if _, err := f.find(containerId); err != nil {
+		return nil, err
+	}
+	return &ContainerStats{}, nil
+}
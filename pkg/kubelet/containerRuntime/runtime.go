@@ -0,0 +1,115 @@
+package containerRuntime
+
+import (
+	"context"
+	"io"
+	"minik8s/object"
+	"time"
+)
+
+// the states of a container
+const (
+	ContainerCreated = "created"
+	ContainerRunning = "running"
+	ContainerExited  = "exited"
+	ContainerDead    = "dead"
+)
+
+// ContainerStatus is what the runtime knows about a container
+type ContainerStatus struct {
+	Id    string
+	Name  string
+	State string
+	// ExitCode, Error and OOMKilled are about the last exit of the container
+	ExitCode   int
+	Error      string
+	OOMKilled  bool
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// IPAddress is only set for the sandbox, the containers share its network
+	IPAddress string
+}
+
+// LogOptions selects the logs ContainerLogs returns
+type LogOptions struct {
+	// Follow keeps the stream open and writes the new logs until ctx is done
+	Follow     bool
+	Timestamps bool
+	// TailLines is how many lines to return from the end of the logs, nil is all of them
+	TailLines *int64
+	// Since and Until bound the time the logs were written at when they are not zero,
+	// the logs of one run of a container which restarted are between its start and its end
+	Since time.Time
+	Until time.Time
+}
+
+// TerminalSize is the size of the terminal of a command run with a tty
+type TerminalSize struct {
+	Width  uint16
+	Height uint16
+}
+
+// ExecOptions are the command Exec runs and the streams attached to it
+type ExecOptions struct {
+	Cmd []string
+	Tty bool
+	// Stdin is nil if the command reads nothing, the stderr of a command with a tty is in Stdout
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Resize carries the new sizes of the terminal, it may be nil
+	Resize <-chan TerminalSize
+}
+
+// ContainerStats is the resource usage of a container
+type ContainerStats struct {
+	CPUPercent    float64
+	MemoryPercent float64
+}
+
+// ContainerRuntime runs the containers of the pods, the kubelet only talks to the containers through it.
+// A pod is a sandbox holding the network and ipc namespaces, and the containers joining them.
+type ContainerRuntime interface {
+	// PullImages pulls the images which are not on the node yet
+	PullImages(images []string) error
+	// CreateSandbox creates the sandbox of a pod exposing ports, it is started with StartContainer
+	CreateSandbox(name string, ports []object.Port) (string, error)
+	// CreateContainer creates container in the sandbox, its volume mounts are host paths
+	CreateContainer(sandboxId string, container *object.Container) (string, error)
+	StartContainer(containerId string) error
+	StopContainer(containerId string) error
+	// RemoveContainer stops and removes a container or a sandbox by its id or its name
+	RemoveContainer(containerId string) error
+	InspectContainer(containerId string) (*ContainerStatus, error)
+	ContainerLogs(ctx context.Context, containerId string, options LogOptions) (io.ReadCloser, error)
+	// ExecInContainer runs cmd in the container until it exits or ctx is done,
+	// it returns the exit code and what the command wrote to stdout and stderr
+	ExecInContainer(ctx context.Context, containerId string, cmd []string) (int, string, error)
+	// Exec runs a command in the container with its streams attached until it exits, it returns the exit code
+	Exec(ctx context.Context, containerId string, options ExecOptions) (int, error)
+	// PortForward copies the data between stream and the port of the sandbox until one side closes
+	PortForward(ctx context.Context, sandboxId string, port int32, stream io.ReadWriteCloser) error
+	ContainerStats(containerId string) (*ContainerStats, error)
+}
+
+// CopyStreams copies the data both ways between conn and stream until one side closes or ctx is done,
+// then it closes both of them
+func CopyStreams(ctx context.Context, conn io.ReadWriteCloser, stream io.ReadWriteCloser) error {
+	errs := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(conn, stream)
+		errs <- err
+	}()
+	go func() {
+		_, err := io.Copy(stream, conn)
+		errs <- err
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+	conn.Close()
+	stream.Close()
+	return err
+}
@@ -1,20 +1,24 @@
 package dockerClient
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"minik8s/object"
-	"minik8s/pkg/kubelet/message"
+	"minik8s/pkg/kubelet/containerRuntime"
 	"minik8s/pkg/netSupport/netconfig"
 	"strconv"
-	"unsafe"
+	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -42,33 +46,25 @@ func GetRunningContainers() ([]types.Container, error) {
 	}
 	return cli.ContainerList(context.Background(), types.ContainerListOptions{})
 }
-func startContainer(containerId string) error {
-	cli, err := getNewClient()
-	if err != nil {
-		return err
-	}
-	err = cli.ContainerStart(context.Background(), containerId, types.ContainerStartOptions{})
-	return err
-}
-func stopContainer(containerId string) error {
-	cli, err := getNewClient()
-	if err != nil {
-		return err
-	}
-	err = cli.ContainerStop(context.Background(), containerId, nil)
-	return err
+
+// PauseImage is the image of the sandbox holding the namespaces of a pod
+const PauseImage = "registry.cn-hangzhou.aliyuncs.com/google_containers/pause:3.6"
+
+// DockerRuntime runs the containers of the pods with docker
+type DockerRuntime struct {
+	cli *client.Client
 }
-func getPodNetworkSettings(containerId string) (*types.NetworkSettings, error) {
+
+var _ containerRuntime.ContainerRuntime = &DockerRuntime{}
+
+func NewDockerRuntime() (*DockerRuntime, error) {
 	cli, err := getNewClient()
 	if err != nil {
 		return nil, err
 	}
-	res, err2 := cli.ContainerInspect(context.Background(), containerId)
-	if err2 != nil {
-		return nil, err
-	}
-	return res.NetworkSettings, nil
+	return &DockerRuntime{cli: cli}, nil
 }
+
 func isImageExist(a string, tags []string) bool {
 	for _, b := range tags {
 		if a == b {
@@ -83,13 +79,9 @@ func isImageExist(a string, tags []string) bool {
 	fmt.Printf("Local image:%v Target image:%s\n", tags, a)
 	return false
 }
-func dockerClientPullImages(images []string) error {
+func (r *DockerRuntime) PullImages(images []string) error {
 	//先统一拉取镜像，确认是否已经存在于本地
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return err2
-	}
-	resp, err := cli.ImageList(context.Background(), types.ImageListOptions{All: true})
+	resp, err := r.cli.ImageList(context.Background(), types.ImageListOptions{All: true})
 	if err != nil {
 		return err
 	}
@@ -109,7 +101,7 @@ func dockerClientPullImages(images []string) error {
 	}
 	if filter != nil {
 		for _, value := range filter {
-			err := dockerClientPullSingleImage(value)
+			err := r.pullSingleImage(value)
 			if err != nil {
 				return err
 			}
@@ -120,13 +112,9 @@ func dockerClientPullImages(images []string) error {
 }
 
 //注意， 调用ImagePull 函数， 拉取进程在后台运行，因此要保证前台挂起足够时间保证拉取成功
-func dockerClientPullSingleImage(image string) error {
+func (r *DockerRuntime) pullSingleImage(image string) error {
 	fmt.Printf("[PullSingleImage] Prepare pull image:%s\n", image)
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return err2
-	}
-	out, err := cli.ImagePull(context.Background(), image, types.ImagePullOptions{})
+	out, err := r.cli.ImagePull(context.Background(), image, types.ImagePullOptions{})
 	if err != nil {
 		fmt.Printf("[PullSingleImage] Fail to pull image, err:%v\n", err)
 		return err
@@ -136,40 +124,11 @@ func dockerClientPullSingleImage(image string) error {
 	return nil
 }
 
-func runContainers(containerIds []object.ContainerMeta) error {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return err2
-	}
-	for _, value := range containerIds {
-		err := cli.ContainerStart(context.Background(), value.ContainerId, types.ContainerStartOptions{})
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
-func getContainersInfo(containerIds []string) ([]types.ContainerJSON, error) {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return nil, err2
-	}
-	var result []types.ContainerJSON
-	for _, value := range containerIds {
-		single, err := cli.ContainerInspect(context.Background(), value)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, single)
-	}
-	return result, nil
-}
-
 //创建pause容器
-func createPause(ports []object.Port, name string) (container.ContainerCreateCreatedBody, error) {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return container.ContainerCreateCreatedBody{}, err2
+func (r *DockerRuntime) CreateSandbox(name string, ports []object.Port) (string, error) {
+	err := r.PullImages([]string{PauseImage})
+	if err != nil {
+		return "", err
 	}
 	var exports nat.PortSet
 	exports = make(nat.PortSet, len(ports))
@@ -177,292 +136,276 @@ func createPause(ports []object.Port, name string) (container.ContainerCreateCre
 		if port.Protocol == "" || port.Protocol == "tcp" || port.Protocol == "all" {
 			p, err := nat.NewPort("tcp", port.ContainerPort)
 			if err != nil {
-				return container.ContainerCreateCreatedBody{}, err
+				return "", err
 			}
 			exports[p] = struct{}{}
 		}
 		if port.Protocol == "udp" || port.Protocol == "all" {
 			p, err := nat.NewPort("udp", port.ContainerPort)
 			if err != nil {
-				return container.ContainerCreateCreatedBody{}, err
+				return "", err
 			}
 			exports[p] = struct{}{}
 		}
 	}
 
-	resp, err := cli.ContainerCreate(context.Background(), &container.Config{
-		Image:        "registry.cn-hangzhou.aliyuncs.com/google_containers/pause:3.6",
+	resp, err := r.cli.ContainerCreate(context.Background(), &container.Config{
+		Image:        PauseImage,
 		ExposedPorts: exports,
 	}, &container.HostConfig{
 		IpcMode: container.IpcMode("shareable"),
 		DNS:     []string{netconfig.ServiceDns},
 	}, nil, nil, name)
-	return resp, err
-}
-
-//检查容器状态
-func probeContainers(containerIds []string) ([]string, error) {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return nil, err2
-	}
-	var res []string
-	for _, value := range containerIds {
-		resp, err := cli.ContainerInspect(context.Background(), value)
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, resp.State.Status)
+	if err != nil {
+		return "", err
 	}
-	return res, nil
+	return resp.ID, nil
 }
 
-//删除containers
-func deleteContainers(containerIds []string) error {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return err2
-	}
-	//需要先停止containers
-	for _, value := range containerIds {
-		err := cli.ContainerStop(context.Background(), value, nil)
-		if err != nil {
-			return err
-		}
+func (r *DockerRuntime) CreateContainer(sandboxId string, value *object.Container) (string, error) {
+	var mounts []mount.Mount
+	for _, it := range value.VolumeMounts {
+		mounts = append(mounts, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: it.Name,
+			Target: it.MountPath,
+		})
 	}
-	for _, value := range containerIds {
-		err := cli.ContainerRemove(context.Background(), value, types.ContainerRemoveOptions{})
-		if err != nil {
-			return err
-		}
+	//生成env
+	var env []string
+	for _, it := range value.Env {
+		env = append(env, it.Name+"="+it.Value)
 	}
-	return nil
+	//生成resource
+	resourceConfig := container.Resources{}
+	cpu, err := object.ParseCpu(value.Limits.Cpu)
+	if err != nil {
+		return "", err
+	}
+	resourceConfig.NanoCPUs = cpu * 1e6
+	resourceConfig.Memory, err = object.ParseMemory(value.Limits.Memory)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.cli.ContainerCreate(context.Background(), &container.Config{
+		Image:      value.Image,
+		Entrypoint: value.Command,
+		Cmd:        value.Args,
+		Env:        env,
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + sandboxId),
+		Mounts:      mounts,
+		IpcMode:     container.IpcMode("container:" + sandboxId),
+		PidMode:     container.PidMode("container" + sandboxId),
+		Resources:   resourceConfig,
+	}, nil, nil, value.Name)
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
 }
 
-//查找是否存在，存在就删除
-func deleteExitedContainers(names []string) error {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return err2
-	}
-	for _, value := range names {
-		_, err := cli.ContainerInspect(context.Background(), value)
-		if err == nil {
-			//需要先停止container
-			err = cli.ContainerStop(context.Background(), value, nil)
-			if err != nil {
-				return err
-			}
-			err = cli.ContainerRemove(context.Background(), value, types.ContainerRemoveOptions{})
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+func (r *DockerRuntime) StartContainer(containerId string) error {
+	return r.cli.ContainerStart(context.Background(), containerId, types.ContainerStartOptions{})
 }
-func getContainerNetInfo(name string) (*types.NetworkSettings, error) {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return nil, err2
+
+func (r *DockerRuntime) StopContainer(containerId string) error {
+	return r.cli.ContainerStop(context.Background(), containerId, nil)
+}
+
+func (r *DockerRuntime) RemoveContainer(containerId string) error {
+	//需要先停止container
+	err := r.cli.ContainerStop(context.Background(), containerId, nil)
+	if err != nil {
+		return err
 	}
-	res, err := cli.ContainerInspect(context.Background(), name)
+	return r.cli.ContainerRemove(context.Background(), containerId, types.ContainerRemoveOptions{})
+}
+
+func (r *DockerRuntime) InspectContainer(containerId string) (*containerRuntime.ContainerStatus, error) {
+	resp, err := r.cli.ContainerInspect(context.Background(), containerId)
 	if err != nil {
 		return nil, err
 	}
-	return res.NetworkSettings, nil
-}
-func getCpu(input string) int64 {
-	len := len(input)
-	result := 0.0
-	if input[len-1] == 'm' {
-		result, _ = strconv.ParseFloat(input[:len-1], 32)
-		result *= 1e6
-	} else {
-		result, _ = strconv.ParseFloat(input[:len], 32)
-		result *= 1e9
-	}
-	return int64(result)
-}
-func getMemory(input string) int64 {
-	len := len(input)
-	result, _ := strconv.Atoi(input[:len-1])
-	mark := input[len-1]
-	if mark == 'K' || mark == 'k' {
-		result *= 1024
-	} else if mark == 'M' || mark == 'm' {
-		result *= 1024 * 1024
-	} else {
-		//G
-		result *= 1024 * 1024 * 1024
-	}
-	return int64(result)
+	status := &containerRuntime.ContainerStatus{
+		Id:        resp.ID,
+		Name:      strings.TrimPrefix(resp.Name, "/"),
+		State:     resp.State.Status,
+		ExitCode:  resp.State.ExitCode,
+		Error:     resp.State.Error,
+		OOMKilled: resp.State.OOMKilled,
+	}
+	status.StartedAt, _ = time.Parse(time.RFC3339Nano, resp.State.StartedAt)
+	status.FinishedAt, _ = time.Parse(time.RFC3339Nano, resp.State.FinishedAt)
+	if resp.NetworkSettings != nil {
+		status.IPAddress = resp.NetworkSettings.IPAddress
+	}
+	return status, nil
 }
-func createContainersOfPod(containers []object.Container) ([]object.ContainerMeta, *types.NetworkSettings, error) {
-	cli, err2 := getNewClient()
-	if err2 != nil {
-		return nil, nil, err2
-	}
-	var firstContainerId string
-	var result []object.ContainerMeta
-	//先生成所有要暴露的port集合
-	var totlePort []object.Port
-	images := []string{"registry.cn-hangzhou.aliyuncs.com/google_containers/pause:3.6"}
-	//防止重名，先检查是否重名，有的话删除
-	var names []string
-	pauseName := "pause"
-	for _, value := range containers {
-		pauseName += "_" + value.Name
-		names = append(names, value.Name)
-		images = append(images, value.Image)
-		for _, port := range value.Ports {
-			totlePort = append(totlePort, port)
-		}
+
+func (r *DockerRuntime) ContainerLogs(ctx context.Context, containerId string, options containerRuntime.LogOptions) (io.ReadCloser, error) {
+	tail := "all"
+	if options.TailLines != nil {
+		tail = strconv.FormatInt(*options.TailLines, 10)
+	}
+	logsOptions := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     options.Follow,
+		Timestamps: options.Timestamps,
+		Tail:       tail,
 	}
-	names = append(names, pauseName)
-	err3 := deleteExitedContainers(names)
-	if err3 != nil {
-		return nil, nil, err3
+	if !options.Since.IsZero() {
+		logsOptions.Since = options.Since.Format(time.RFC3339Nano)
 	}
-	//先统一拉取镜像
-	err := dockerClientPullImages(images)
+	if !options.Until.IsZero() {
+		logsOptions.Until = options.Until.Format(time.RFC3339Nano)
+	}
+	out, err := r.cli.ContainerLogs(ctx, containerId, logsOptions)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	//创建pause容器
-	pause, err := createPause(totlePort, pauseName)
+	// the containers have no tty, stdout and stderr are multiplexed in the stream
+	reader, writer := io.Pipe()
+	go func() {
+		defer out.Close()
+		_, err := stdcopy.StdCopy(writer, writer, out)
+		writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
+
+func (r *DockerRuntime) ExecInContainer(ctx context.Context, containerId string, cmd []string) (int, string, error) {
+	var output bytes.Buffer
+	exitCode, err := r.Exec(ctx, containerId, containerRuntime.ExecOptions{Cmd: cmd, Stdout: &output, Stderr: &output})
 	if err != nil {
-		return nil, nil, err
+		return 0, "", err
 	}
-	firstContainerId = pause.ID
-	result = append(result, object.ContainerMeta{
-		RealName:    pauseName,
-		ContainerId: firstContainerId,
+	return exitCode, output.String(), nil
+}
+
+func (r *DockerRuntime) Exec(ctx context.Context, containerId string, options containerRuntime.ExecOptions) (int, error) {
+	exec, err := r.cli.ContainerExecCreate(ctx, containerId, types.ExecConfig{
+		Cmd:          options.Cmd,
+		Tty:          options.Tty,
+		AttachStdin:  options.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
 	})
-	for _, value := range containers {
-		var mounts []mount.Mount
-		if value.VolumeMounts != nil {
-			for _, it := range value.VolumeMounts {
-				mounts = append(mounts, mount.Mount{
-					Type:   mount.TypeBind,
-					Source: it.Name,
-					Target: it.MountPath,
-				})
-			}
-		}
-		//生成env
-		var env []string
-		if value.Env != nil {
-			for _, it := range value.Env {
-				singleEnv := it.Name + "=" + it.Value
-				env = append(env, singleEnv)
+	if err != nil {
+		return 0, err
+	}
+	attach, err := r.cli.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{Tty: options.Tty})
+	if err != nil {
+		return 0, err
+	}
+	defer attach.Close()
+	if options.Stdin != nil {
+		go func() {
+			_, _ = io.Copy(attach.Conn, options.Stdin)
+			_ = attach.CloseWrite()
+		}()
+	}
+	if options.Resize != nil {
+		go func() {
+			for {
+				select {
+				case size, ok := <-options.Resize:
+					if !ok {
+						return
+					}
+					_ = r.cli.ContainerExecResize(ctx, exec.ID, types.ResizeOptions{Height: uint(size.Height), Width: uint(size.Width)})
+				case <-ctx.Done():
+					return
+				}
 			}
+		}()
+	}
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		if options.Tty {
+			_, err = io.Copy(options.Stdout, attach.Reader)
+		} else {
+			_, err = stdcopy.StdCopy(options.Stdout, options.Stderr, attach.Reader)
 		}
-		//生成resource
-		resourceConfig := container.Resources{}
-		if value.Limits.Cpu != "" {
-			resourceConfig.NanoCPUs = getCpu(value.Limits.Cpu)
-		}
-		if value.Limits.Memory != "" {
-			resourceConfig.Memory = getMemory(value.Limits.Memory)
-		}
-		resp, err := cli.ContainerCreate(context.Background(), &container.Config{
-			Image:      value.Image,
-			Entrypoint: value.Command,
-			Cmd:        value.Args,
-			Env:        env,
-		}, &container.HostConfig{
-			NetworkMode: container.NetworkMode("container:" + firstContainerId),
-			Mounts:      mounts,
-			IpcMode:     container.IpcMode("container:" + firstContainerId),
-			PidMode:     container.PidMode("container" + firstContainerId),
-			Resources:   resourceConfig,
-		}, nil, nil, value.Name)
+		done <- err
+	}()
+	select {
+	case err = <-done:
 		if err != nil {
-			return nil, nil, err
+			return 0, err
 		}
-		result = append(result, object.ContainerMeta{
-			RealName:    value.Name,
-			ContainerId: resp.ID,
-		})
+	case <-ctx.Done():
+		return 0, ctx.Err()
 	}
-	//启动容器
-	err = runContainers(result)
+	inspect, err := r.cli.ContainerExecInspect(ctx, exec.ID)
 	if err != nil {
-		return nil, nil, err
+		return 0, err
 	}
-	var netSetting *types.NetworkSettings
-	netSetting, err = getContainerNetInfo(pauseName)
+	return inspect.ExitCode, nil
+}
+
+// PortForward connects to the port from the network namespace of the pause container,
+// so that the port is reached even if the pod only listens on localhost
+func (r *DockerRuntime) PortForward(ctx context.Context, sandboxId string, port int32, stream io.ReadWriteCloser) error {
+	resp, err := r.cli.ContainerInspect(ctx, sandboxId)
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+	if resp.State == nil || !resp.State.Running {
+		return fmt.Errorf("sandbox %s is not running", sandboxId)
+	}
+	conn, err := dialInNetns(resp.State.Pid, "tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return err
 	}
-	return result, netSetting, nil
+	return containerRuntime.CopyStreams(ctx, conn, stream)
 }
 
-//涉及大量指针操作，要确保在caller和callee在同一个地址空间中
-func HandleCommand(command *message.Command) *message.Response {
-
-	switch command.CommandType {
-	case message.COMMAND_GET_ALL_CONTAINER:
-		containers, err := GetAllContainers()
-		var result message.ResponseWithContainInfo
-		result.Response.CommandType = message.COMMAND_GET_ALL_CONTAINER
-		result.Response.Err = err
-		result.Containers = containers
-		return &(result.Response)
-	case message.COMMAND_GET_RUNNING_CONTAINER:
-		containers, err := GetRunningContainers()
-		var result message.ResponseWithContainInfo
-		result.Response.CommandType = message.COMMAND_GET_RUNNING_CONTAINER
-		result.Response.Err = err
-		result.Containers = containers
-		return &(result.Response)
-	case message.COMMAND_RUN_CONTAINER:
-		p := (*message.CommandWithId)(unsafe.Pointer(command))
-		err := startContainer(p.ContainerId)
-		var result message.Response
-		result.CommandType = message.COMMAND_RUN_CONTAINER
-		result.Err = err
-		return &result
-	case message.COMMAND_STOP_CONTAINER:
-		p := (*message.CommandWithId)(unsafe.Pointer(command))
-		err := stopContainer(p.ContainerId)
-		var result message.Response
-		result.CommandType = message.COMMAND_STOP_CONTAINER
-		result.Err = err
-		return &result
-	case message.COMMAND_BUILD_CONTAINERS_OF_POD:
-		p := (*message.CommandWithConfig)(unsafe.Pointer(command))
-		res, netSetting, err := createContainersOfPod(p.Group)
-		var result message.ResponseWithContainIds
-		result.Err = err
-		result.CommandType = message.COMMAND_BUILD_CONTAINERS_OF_POD
-		result.Containers = res
-		result.NetWorkInfos = netSetting
-		return &(result.Response)
-	case message.COMMAND_PULL_IMAGES:
-		p := (*message.CommandWithImages)(unsafe.Pointer(command))
-		err := dockerClientPullImages(p.Images)
-		var result message.Response
-		result.CommandType = message.COMMAND_PULL_IMAGES
-		result.Err = err
-		return &result
-	case message.COMMAND_PROBE_CONTAINER:
-		p := (*message.CommandWithContainerIds)(unsafe.Pointer(command))
-		res, err := probeContainers(p.ContainerIds)
-		var result message.ResponseWithProbeInfos
-		result.Err = err
-		result.CommandType = message.COMMAND_PROBE_CONTAINER
-		result.ProbeInfos = res
-		return &(result.Response)
-	case message.COMMAND_DELETE_CONTAINER:
-		//删除containers的操作
-		p := (*message.CommandWithContainerIds)(unsafe.Pointer(command))
-		err := deleteContainers(p.ContainerIds)
-		var result message.Response
-		result.CommandType = message.COMMAND_DELETE_CONTAINER
-		result.Err = err
-		return &result
+func (r *DockerRuntime) ContainerStats(containerId string) (*containerRuntime.ContainerStats, error) {
+	stats, err := r.cli.ContainerStats(context.Background(), containerId, false)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	defer stats.Body.Close()
+	statsJson := &types.StatsJSON{}
+	err = json.NewDecoder(stats.Body).Decode(statsJson)
+	if err != nil {
+		return nil, err
+	}
+	return &containerRuntime.ContainerStats{
+		CPUPercent:    getCPUPercent(statsJson),
+		MemoryPercent: getMemPercent(statsJson),
+	}, nil
+}
+
+func getCPUPercent(statsJson *types.StatsJSON) float64 {
+	// cpuPercent = (cpuDelta / systemDelta) * onlineCPUs * 100.0
+	var preCPUUsage uint64
+	var CPUUsage uint64
+	for _, core := range statsJson.CPUStats.CPUUsage.PercpuUsage {
+		CPUUsage += core
+	}
+	for _, core := range statsJson.PreCPUStats.CPUUsage.PercpuUsage {
+		preCPUUsage += core
+	}
+	systemUsage := statsJson.CPUStats.SystemUsage
+	preSystemUsage := statsJson.PreCPUStats.SystemUsage
+
+	deltaCPU := CPUUsage - preCPUUsage
+	deltaSystem := systemUsage - preSystemUsage
+
+	onlineCPU := statsJson.CPUStats.OnlineCPUs
+
+	cpuPercent := (float64(deltaCPU) / float64(deltaSystem)) * float64(onlineCPU) * 100.0
+	return cpuPercent
+}
+
+func getMemPercent(statsJson *types.StatsJSON) float64 {
+	// MEM USAGE / LIMIT
+	usage := statsJson.MemoryStats.Usage
+	maxUsage := statsJson.MemoryStats.Limit
+	percentage := float64(usage) / float64(maxUsage) * 100.0
+	return percentage
 }
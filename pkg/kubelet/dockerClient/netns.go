@@ -0,0 +1,41 @@
+package dockerClient
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"net"
+	"os"
+	"runtime"
+)
+
+// dialInNetns dials address from the network namespace of the process pid.
+// The socket stays in that namespace once the thread is back in its own.
+func dialInNetns(pid int, network string, address string) (net.Conn, error) {
+	runtime.LockOSThread()
+	origin, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", unix.Gettid()))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	defer origin.Close()
+	target, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	defer target.Close()
+	if err = unix.Setns(int(target.Fd()), unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	conn, dialErr := net.Dial(network, address)
+	if err = unix.Setns(int(origin.Fd()), unix.CLONE_NEWNET); err != nil {
+		// the thread is left locked so that it exits with the goroutine instead of running others in the pod network
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+	runtime.UnlockOSThread()
+	return conn, dialErr
+}
@@ -0,0 +1,69 @@
+package kubelet
+
+import (
+	"fmt"
+	"minik8s/object"
+	"time"
+)
+
+const (
+	// leaseDurationSeconds is how long a heartbeat lasts, the node lifecycle controller waits a bit longer
+	leaseDurationSeconds = 40
+	// heartbeatInterval is how often the lease of the node is renewed
+	heartbeatInterval = 10 * time.Second
+	// nodeStatusReportInterval is how often the Ready condition is refreshed while the node stays ready
+	nodeStatusReportInterval = time.Minute
+)
+
+// heartbeat renews the lease of the node and reports it ready,
+// the node lifecycle controller marks the node NotReady once the lease is expired
+func (kl *Kubelet) heartbeat() {
+	var lastReport time.Time
+	for {
+		snapshot := kl.kubeNetSupport.GetKubeproxySnapShoot()
+		// the node is not registered yet
+		if snapshot.NodeName != "" {
+			now := time.Now()
+			lease := &object.Lease{
+				ObjectMeta: object.ObjectMeta{Name: snapshot.NodeName},
+				Spec: object.LeaseSpec{
+					HolderIdentity:       snapshot.NodeName,
+					LeaseDurationSeconds: leaseDurationSeconds,
+					RenewTime:            now,
+				},
+			}
+			err := kl.Client.RenewLease(snapshot.MyDynamicIp, lease)
+			if err != nil {
+				fmt.Printf("[heartbeat] renew lease fail: %s\n", err.Error())
+			}
+			node, err := kl.Client.GetNode(snapshot.MyDynamicIp)
+			if err == nil && node != nil && (!node.IsReady() || now.Sub(lastReport) >= nodeStatusReportInterval) {
+				if kl.reportNodeReady(snapshot.MyDynamicIp, now) == nil {
+					lastReport = now
+				}
+			}
+		}
+		select {
+		case <-kl.stopChannel:
+			return
+		case <-time.After(heartbeatInterval):
+		}
+	}
+}
+
+func (kl *Kubelet) reportNodeReady(dynamicIp string, now time.Time) error {
+	_, err := kl.Client.UpdateNode(dynamicIp, func(node *object.Node) error {
+		node.SetCondition(object.NodeCondition{
+			Type:              object.NodeReady,
+			Status:            object.ConditionTrue,
+			LastHeartbeatTime: now,
+			Reason:            "KubeletReady",
+			Message:           "kubelet is posting ready status",
+		}, now)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("[heartbeat] report node status fail: %s\n", err.Error())
+	}
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"minik8s/pkg/client"
 	"minik8s/pkg/etcdstore"
 	"minik8s/pkg/klog"
+	"minik8s/pkg/kubelet/dockerClient"
 	"minik8s/pkg/kubelet/monitor"
 	"minik8s/pkg/kubelet/podConfig"
 	"minik8s/pkg/kubelet/podManager"
@@ -37,7 +38,11 @@ type Kubelet struct {
 
 func NewKubelet(lsConfig *listerwatcher.Config, clientConfig client.Config, node *object.Node) *Kubelet {
 	kubelet := &Kubelet{}
-	kubelet.podManager = podManager.NewPodManager(clientConfig)
+	runtime, err := dockerClient.NewDockerRuntime()
+	if err != nil {
+		fmt.Printf("[NewKubelet] new docker runtime fail")
+	}
+	kubelet.podManager = podManager.NewPodManager(clientConfig, runtime)
 	restClient := client.RESTClient{
 		Base: "http://" + clientConfig.Host,
 	}
@@ -57,7 +62,7 @@ func NewKubelet(lsConfig *listerwatcher.Config, clientConfig client.Config, node
 	// initialize pod podConfig
 	kubelet.PodConfig = podConfig.NewPodConfig()
 
-	kubelet.podMonitor = monitor.NewDockerMonitor()
+	kubelet.podMonitor = monitor.NewDockerMonitor(runtime)
 
 	return kubelet
 }
@@ -71,8 +76,11 @@ func (kl *Kubelet) Run() {
 	kl.kubeProxy.StartKubeProxy()
 	updates := kl.PodConfig.GetUpdates()
 	go kl.podMonitor.Listener()
+	go kl.serve()
 	go kl.syncLoop(updates, kl)
 	go kl.DoMonitor(context.Background())
+	go kl.heartbeat()
+	go kl.taintEviction()
 	go func() {
 		err := kl.ls.Watch(config.PodConfigPREFIX, kl.watchPod, kl.stopChannel)
 		if err != nil {
@@ -100,8 +108,8 @@ func (kl *Kubelet) syncLoop(updates <-chan types.PodUpdate, handler SyncHandler)
 func (k *Kubelet) AddPod(pod *object.Pod) error {
 	return k.podManager.AddPod(pod)
 }
-func (k *Kubelet) DeletePod(podName string) error {
-	return k.podManager.DeletePod(podName)
+func (k *Kubelet) DeletePod(namespace string, podName string) error {
+	return k.podManager.DeletePod(namespace, podName)
 }
 
 type SyncHandler interface {
@@ -148,7 +156,7 @@ func (kl *Kubelet) watchPod(res etcdstore.WatchRes) {
 	}
 	fmt.Printf("[watchPod] New message...\n")
 	pods := []*object.Pod{pod}
-	ok := kl.podManager.CheckIfPodExist(pod.Name)
+	ok := kl.podManager.CheckIfPodExist(pod.Namespace, pod.Name)
 	if !ok {
 		//pod 不存在,
 		if pod.Spec.NodeName != kl.getNodeName() {
@@ -210,7 +218,7 @@ func (kl *Kubelet) HandlePodAdditions(pods []*object.Pod) {
 func (kl *Kubelet) HandlePodUpdates(pods []*object.Pod) {
 	//先删除原来的再增加新的
 	for _, pod := range pods {
-		err := kl.podManager.DeletePod(pod.Name)
+		err := kl.podManager.DeletePod(pod.Namespace, pod.Name)
 		if err != nil {
 			fmt.Printf("[Kubelet] Delete pod fail...")
 			fmt.Printf(err.Error())
@@ -231,7 +239,7 @@ func (kl *Kubelet) HandlePodUpdates(pods []*object.Pod) {
 func (kl *Kubelet) HandlePodRemoves(pods []*object.Pod) {
 	for _, pod := range pods {
 		fmt.Printf("[Kubelet] Prepare delete pod:%+v\n", pod)
-		err := kl.podManager.DeletePod(pod.Name)
+		err := kl.podManager.DeletePod(pod.Namespace, pod.Name)
 		if err != nil {
 			fmt.Printf("[Kubelet] Delete pod fail...\n")
 		}
@@ -1,19 +1,18 @@
 package message
 
 import (
-	"github.com/docker/docker/api/types"
 	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
 )
 
 //---------------------------Container Part---------------------------------------//
-const COMMAND_GET_ALL_CONTAINER = 0
-const COMMAND_GET_RUNNING_CONTAINER = 1
 const COMMAND_RUN_CONTAINER = 2
 const COMMAND_STOP_CONTAINER = 3
 const COMMAND_BUILD_CONTAINERS_OF_POD = 4
 const COMMAND_PULL_IMAGES = 5
 const COMMAND_PROBE_CONTAINER = 6
 const COMMAND_DELETE_CONTAINER = 7
+const COMMAND_RESTART_CONTAINER = 8
 
 //------------------------------------------------------------------------------//
 
@@ -21,6 +20,8 @@ const COMMAND_DELETE_CONTAINER = 7
 const ADD_POD = 0
 const DELETE_POD = 1
 const PROBE_POD = 2
+const RESTART_CONTAINER = 3
+const STOP_CONTAINER = 4
 
 //-------------------------------------------------------------------------------//
 type Command struct {
@@ -51,21 +52,19 @@ type Response struct {
 	CommandType int
 	Err         error
 }
-type ResponseWithContainInfo struct {
-	Response
-	Containers []types.Container
-}
-
 type ResponseWithContainIds struct {
 	Response
-	Containers   []object.ContainerMeta
-	NetWorkInfos *types.NetworkSettings
+	Containers []object.ContainerMeta
+	// PodIP is the address of the sandbox the containers share
+	PodIP string
 }
 
 //返回的切片中元素顺序与commnad中容器id顺序一一对应
 type ResponseWithProbeInfos struct {
 	Response
 	ProbeInfos []string
+	// States are the whole states the ProbeInfos are the status of, with the exit codes and times
+	States []containerRuntime.ContainerStatus
 }
 
 type PodCommand struct {
@@ -2,13 +2,10 @@ package monitor
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"io/ioutil"
 	"log"
+	"minik8s/pkg/kubelet/containerRuntime"
 	"minik8s/pkg/kubelet/pod"
 	"net/http"
 )
@@ -17,20 +14,13 @@ type IMonitor interface {
 }
 
 type DockerMonitor struct {
-	dockerClient *client.Client
+	runtime containerRuntime.ContainerRuntime
 }
 
-func NewDockerMonitor() *DockerMonitor {
+func NewDockerMonitor(runtime containerRuntime.ContainerRuntime) *DockerMonitor {
 	fmt.Printf("[NewDockerMonitor] Init enter\n")
-	c, err := client.NewClientWithOpts()
-	if err != nil {
-		fmt.Printf("[NewDockerMonitor] Init client fail\n")
-		return nil
-	}
-
-	fmt.Printf("[NewDockerMonitor] Init client\n")
 	return &DockerMonitor{
-		dockerClient: c,
+		runtime: runtime,
 	}
 
 }
@@ -48,67 +38,15 @@ func (m *DockerMonitor) MetricDockerStat(ctx context.Context, pod *pod.Pod) {
 	containers := pod.GetContainers()
 	for _, container := range containers {
 		containerID := container.ContainerId
-		stats, err := m.dockerClient.ContainerStats(ctx, containerID, false)
+		stats, err := m.runtime.ContainerStats(containerID)
 		if err != nil {
 			fmt.Printf("[MetricDockerStat] Get stats error:%v\n", err)
 			continue
 		}
-		raw, _ := ioutil.ReadAll(stats.Body)
-		//fmt.Printf("[MetricDockerStat] Stat info:%v\n", string(raw))
 
-		// unmarshal json
-		statsJson := &types.StatsJSON{}
-		err = json.Unmarshal(raw, statsJson)
-		if err != nil {
-			fmt.Printf("[MetricDockerStat] Unmarshal fail,err:%v", err)
-			continue
-		}
-
-		// set metrics
-		memPercent := getMemPercent(statsJson)
-		cpuPercent := getCPUPercent(statsJson)
-
-		//// set metrics, test function
-		//memPercent := m.r.Float64()
-		//cpuPercent := m.r.Float64()
-
-		// fmt.Printf("[MetricDockerStat] cpu:%f%%  mem:%f%%\n", cpuPercent, memPercent)
+		// fmt.Printf("[MetricDockerStat] cpu:%f%%  mem:%f%%\n", stats.CPUPercent, stats.MemoryPercent)
 
 		serviceTag := selectorAppTag(pod.GetLabel())
-		MakeMetricRecord(pod.GetName(), pod.GetUid(), serviceTag, memPercent, cpuPercent)
-	}
-}
-
-func getCPUPercent(statsJson *types.StatsJSON) float64 {
-	// cpuPercent = (cpuDelta / systemDelta) * onlineCPUs * 100.0
-	var preCPUUsage uint64
-	var CPUUsage uint64
-	preCPUUsage = 0
-	CPUUsage = 0
-	for _, core := range statsJson.CPUStats.CPUUsage.PercpuUsage {
-		CPUUsage += core
-	}
-	for _, core := range statsJson.PreCPUStats.CPUUsage.PercpuUsage {
-		preCPUUsage += core
+		MakeMetricRecord(pod.GetName(), pod.GetUid(), serviceTag, stats.MemoryPercent, stats.CPUPercent)
 	}
-	systemUsage := statsJson.CPUStats.SystemUsage
-	preSystemUsage := statsJson.PreCPUStats.SystemUsage
-
-	deltaCPU := CPUUsage - preCPUUsage
-	deltaSystem := systemUsage - preSystemUsage
-
-	onlineCPU := statsJson.CPUStats.OnlineCPUs
-
-	// fmt.Printf("deltaCPU:%v deltaSystem:%v onlineCPU:%v\n", deltaCPU, deltaSystem, onlineCPU)
-
-	cpuPercent := (float64(deltaCPU) / float64(deltaSystem)) * float64(onlineCPU) * 100.0
-	return cpuPercent
-}
-
-func getMemPercent(statsJson *types.StatsJSON) float64 {
-	// MEM USAGE / LIMIT
-	usage := statsJson.MemoryStats.Usage
-	maxUsage := statsJson.MemoryStats.Limit
-	percentage := float64(usage) / float64(maxUsage) * 100.0
-	return percentage
 }
@@ -0,0 +1,79 @@
+package nodeStatus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"minik8s/object"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// the resources reserved for the system daemons and the minik8s components of the node
+const (
+	reservedCpu    int64 = 100       // millicores
+	reservedMemory int64 = 256 << 20 // bytes
+)
+
+// GetNodeStatus returns the capacity of the machine and the resources allocatable to the pods.
+// The allocatable resources set in the node config file are kept, the others default to capacity minus reserved.
+func GetNodeStatus(configured object.NodeStatus) object.NodeStatus {
+	cpu := int64(runtime.NumCPU()) * 1000
+	memory, err := getTotalMemory()
+	if err != nil {
+		fmt.Println("[nodeStatus] read memory fail: " + err.Error())
+	}
+	status := object.NodeStatus{
+		Capacity: object.Limit{
+			Cpu:    formatCpu(cpu),
+			Memory: formatMemory(memory),
+		},
+		Allocatable: configured.Allocatable,
+	}
+	if status.Allocatable.Cpu == "" {
+		status.Allocatable.Cpu = formatCpu(max(cpu-reservedCpu, 0))
+	}
+	if status.Allocatable.Memory == "" {
+		status.Allocatable.Memory = formatMemory(max(memory-reservedMemory, 0))
+	}
+	return status
+}
+
+// getTotalMemory reads MemTotal from /proc/meminfo
+func getTotalMemory() (int64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// MemTotal:       16303552 kB
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kb << 10, nil
+		}
+	}
+	return 0, errors.New("MemTotal not found in /proc/meminfo")
+}
+
+func formatCpu(milli int64) string {
+	return strconv.FormatInt(milli, 10) + "m"
+}
+
+func formatMemory(bytes int64) string {
+	return strconv.FormatInt(bytes>>10, 10) + "Ki"
+}
+
+func max(a int64, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
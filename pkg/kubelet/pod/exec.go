@@ -0,0 +1,34 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"minik8s/pkg/kubelet/containerRuntime"
+)
+
+// Exec runs a command in the container named name, which may be empty if the pod has only one container
+func (p *Pod) Exec(ctx context.Context, name string, options containerRuntime.ExecOptions) (int, error) {
+	p.rwLock.RLock()
+	_, containerId, err := p.findContainer(name)
+	p.rwLock.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	return p.runtime.Exec(ctx, containerId, options)
+}
+
+// PortForward connects stream to the port of the pod, in the network its containers share
+func (p *Pod) PortForward(ctx context.Context, port int32, stream io.ReadWriteCloser) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port %d", port)
+	}
+	p.rwLock.RLock()
+	sandboxId := p.containers[0].ContainerId
+	running := p.getStatus() == POD_RUNNING_STATUS
+	p.rwLock.RUnlock()
+	if !running || sandboxId == "" {
+		return fmt.Errorf("pod %s is not running", p.GetName())
+	}
+	return p.runtime.PortForward(ctx, sandboxId, port, stream)
+}
@@ -0,0 +1,73 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"minik8s/pkg/kubelet/containerRuntime"
+)
+
+// ContainerLogs returns the logs of the current run of the container named name, or of its previous run.
+// The name may be empty if the pod has only one container.
+func (p *Pod) ContainerLogs(ctx context.Context, name string, previous bool, options containerRuntime.LogOptions) (io.ReadCloser, error) {
+	p.rwLock.RLock()
+	containerId, err := p.logContainer(name, previous, &options)
+	p.rwLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if !previous {
+		state, err := p.runtime.InspectContainer(containerId)
+		if err != nil {
+			return nil, err
+		}
+		// a restarted container keeps the logs of its previous runs
+		options.Since = state.StartedAt
+	}
+	return p.runtime.ContainerLogs(ctx, containerId, options)
+}
+
+// logContainer returns the id of the container named name, and bounds options to its previous run if asked.
+// It is called with the lock held.
+func (p *Pod) logContainer(name string, previous bool, options *containerRuntime.LogOptions) (string, error) {
+	name, containerId, err := p.findContainer(name)
+	if err != nil {
+		return "", err
+	}
+	if previous {
+		index := p.containerIndex(name)
+		if index < 0 || p.configPod.Status.ContainerStatuses[index].LastTerminationState.Terminated == nil {
+			return "", fmt.Errorf("previous terminated container %s in pod %s not found", name, p.GetName())
+		}
+		last := p.configPod.Status.ContainerStatuses[index].LastTerminationState.Terminated
+		options.Since, options.Until = last.StartedAt, last.FinishedAt
+	}
+	return containerId, nil
+}
+
+// findContainer returns the name and the id of the container named name, which may be empty
+// if the pod has only one container. It is called with the lock held.
+func (p *Pod) findContainer(name string) (string, string, error) {
+	// the first container is pause
+	containers := p.containers[1:]
+	if name == "" {
+		if len(containers) != 1 {
+			var names []string
+			for _, meta := range containers {
+				names = append(names, meta.OriginName)
+			}
+			return "", "", fmt.Errorf("a container name must be specified for pod %s, choose one of: %v", p.GetName(), names)
+		}
+		name = containers[0].OriginName
+	}
+	for _, meta := range containers {
+		if meta.OriginName != name {
+			continue
+		}
+		if meta.ContainerId == "" {
+			return "", "", fmt.Errorf("container %s in pod %s is waiting to start", name, p.GetName())
+		}
+		return name, meta.ContainerId, nil
+	}
+	return "", "", fmt.Errorf("container %s is not valid for pod %s", name, p.GetName())
+}
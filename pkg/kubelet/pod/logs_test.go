@@ -0,0 +1,65 @@
+package pod
+
+import (
+	"context"
+	"gotest.tools/v3/assert"
+	"io/ioutil"
+	"minik8s/object"
+	"minik8s/pkg/client"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func readLogs(t *testing.T, p *Pod, name string, previous bool, options containerRuntime.LogOptions) string {
+	logs, err := p.ContainerLogs(context.Background(), name, previous, options)
+	assert.NilError(t, err)
+	defer logs.Close()
+	raw, err := ioutil.ReadAll(logs)
+	assert.NilError(t, err)
+	return string(raw)
+}
+
+func TestContainerLogs(t *testing.T) {
+	apiserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer apiserver.Close()
+	runtime := containerRuntime.NewFakeRuntime()
+	config := &object.Pod{
+		ObjectMeta: object.ObjectMeta{Name: "web"},
+		Spec: object.PodSpec{
+			Containers: []object.Container{{Name: "nginx", Image: "nginx"}, {Name: "sidecar", Image: "busybox"}},
+		},
+	}
+	p := NewPodfromConfig(config, client.Config{Host: strings.TrimPrefix(apiserver.URL, "http://")}, runtime)
+	defer p.DeletePod()
+	waitFor(t, p.phaseIs(POD_RUNNING_STATUS))
+
+	_, err := p.ContainerLogs(context.Background(), "", false, containerRuntime.LogOptions{})
+	assert.ErrorContains(t, err, "a container name must be specified for pod web, choose one of: [nginx sidecar]")
+	_, err = p.ContainerLogs(context.Background(), "db", false, containerRuntime.LogOptions{})
+	assert.ErrorContains(t, err, "container db is not valid for pod web")
+	_, err = p.ContainerLogs(context.Background(), "nginx", true, containerRuntime.LogOptions{})
+	assert.ErrorContains(t, err, "previous terminated container nginx in pod web not found")
+
+	assert.NilError(t, runtime.Log("web_nginx", "first"))
+	assert.NilError(t, runtime.Log("web_nginx", "crashing"))
+	assert.Equal(t, "first\ncrashing\n", readLogs(t, p, "nginx", false, containerRuntime.LogOptions{}))
+	var tail int64 = 1
+	assert.Equal(t, "crashing\n", readLogs(t, p, "nginx", false, containerRuntime.LogOptions{TailLines: &tail}))
+
+	// the container crashes and runs again, the logs of the crashed run are the previous ones
+	nginx, _ := runtime.Container("web_nginx")
+	assert.NilError(t, runtime.Exit(nginx.Status.Id, 1))
+	p.probe()
+	waitFor(t, func() bool {
+		p.rwLock.RLock()
+		defer p.rwLock.RUnlock()
+		return p.configPod.Status.ContainerStatuses[0].LastTerminationState.Terminated != nil
+	})
+	assert.NilError(t, runtime.StartContainer(nginx.Status.Id))
+	assert.NilError(t, runtime.Log("web_nginx", "second"))
+	assert.Equal(t, "second\n", readLogs(t, p, "nginx", false, containerRuntime.LogOptions{}))
+	assert.Equal(t, "first\ncrashing\n", readLogs(t, p, "nginx", true, containerRuntime.LogOptions{}))
+}
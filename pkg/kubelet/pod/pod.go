@@ -1,17 +1,18 @@
 package pod
 
 import (
-	"context"
 	"fmt"
-	"github.com/docker/docker/api/types"
 	"github.com/satori/go.uuid"
 	"minik8s/object"
+	apiConfig "minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/kubelet/dockerClient"
+	"minik8s/pkg/kubelet/containerRuntime"
 	"minik8s/pkg/kubelet/message"
 	"minik8s/pkg/kubelet/podWorker"
+	"minik8s/pkg/kubelet/probe"
 	"os"
 	"path"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
@@ -30,6 +31,7 @@ const POD_RUNNING_STATUS = "Running"
 const POD_EXITED_STATUS = "Exited"
 const POD_DELETED_STATUS = "deleted"
 const POD_CREATEED_STATUS = "created"
+const POD_SUCCEEDED_STATUS = "Succeeded"
 
 //container 的状态
 const CONTAINER_EXITED_STATUS = "exited"
@@ -37,7 +39,7 @@ const CONTAINER_RUNNING_STATUS = "running"
 const CONTAINER_CREATED_STATUS = "created"
 
 //pod探针间隔,为了防止探针command拥堵，要等上一次的response
-const PROBE_INTERVAL = 60 //探针间隔，单位为秒
+const PROBE_INTERVAL = 10 //探针间隔，单位为秒
 
 type Pod struct {
 	configPod   *object.Pod
@@ -50,11 +52,16 @@ type Pod struct {
 	commandChan  chan message.PodCommand
 	responseChan chan message.PodResponse
 	podWorker    *podWorker.PodWorker
+	runtime      containerRuntime.ContainerRuntime
 	//探针相关
 	timer        *time.Ticker
 	canProbeWork bool
 	stopChan     chan bool
 	client       client.RESTClient
+	// prober runs the liveness, readiness and startup probes of the containers once they are created
+	prober *probe.Manager
+	// backoffs delay the restarts of the exited containers by their names
+	backoffs map[string]*containerBackoff
 }
 
 type PodNetWork struct {
@@ -68,12 +75,18 @@ type PodNetWork struct {
 func (p *Pod) GetName() string {
 	return p.configPod.Name
 }
+func (p *Pod) GetNamespace() string {
+	return p.configPod.Namespace
+}
 func (p *Pod) GetLabel() map[string]string {
 	return p.configPod.Labels
 }
 func (p *Pod) GetUid() string {
 	return p.configPod.UID
 }
+func (p *Pod) GetTolerations() []object.Toleration {
+	return p.configPod.Spec.Tolerations
+}
 func (p *Pod) GetContainers() []object.ContainerMeta {
 	p.rwLock.RLock()
 	defer p.rwLock.RUnlock()
@@ -107,7 +120,7 @@ func (p *Pod) uploadPod() {
 //--------------------------------------------------------------//
 
 //------初始化相关函数--------//
-func NewPodfromConfig(config *object.Pod, clientConfig client.Config) *Pod {
+func NewPodfromConfig(config *object.Pod, clientConfig client.Config, runtime containerRuntime.ContainerRuntime) *Pod {
 	newPod := &Pod{}
 	newPod.configPod = config
 	newPod.configPod.Ctime = time.Now().Format("2006-01-02 15:04:05")
@@ -120,7 +133,8 @@ func NewPodfromConfig(config *object.Pod, clientConfig client.Config) *Pod {
 	newPod.client = restClient
 	newPod.commandChan = make(chan message.PodCommand, 100)
 	newPod.responseChan = make(chan message.PodResponse, 100)
-	newPod.podWorker = &podWorker.PodWorker{}
+	newPod.runtime = runtime
+	newPod.podWorker = podWorker.NewPodWorker(runtime)
 	//创建pod里的containers同时把config里的originName替换为realName
 	//先填第一个pause容器
 	newPod.containers = append(newPod.containers, object.ContainerMeta{
@@ -128,8 +142,13 @@ func NewPodfromConfig(config *object.Pod, clientConfig client.Config) *Pod {
 		RealName:   "", //先设置为空
 	})
 	pauseRealName := "pause"
+	// pods with the same name may live in different namespaces, so the namespace is part of the container name
+	namePrefix := config.Name
+	if config.Namespace != "" && config.Namespace != apiConfig.DefaultNamespace {
+		namePrefix = config.Namespace + "_" + config.Name
+	}
 	for index, value := range config.Spec.Containers {
-		realName := config.Name + "_" + value.Name
+		realName := namePrefix + "_" + value.Name
 		newPod.containers = append(newPod.containers, object.ContainerMeta{
 			OriginName: value.Name,
 			RealName:   realName,
@@ -179,8 +198,10 @@ func NewPodfromConfig(config *object.Pod, clientConfig client.Config) *Pod {
 		PodCommandType:   message.ADD_POD,
 	}
 	newPod.commandChan <- podCommand
-	//提交pod
+	//提交pod, the response of the command may be changing it already
+	newPod.rwLock.Lock()
 	newPod.uploadPod()
+	newPod.rwLock.Unlock()
 	return newPod
 }
 
@@ -207,7 +228,6 @@ func (p *Pod) listeningResponse() {
 				responseWithContainIds := (*message.ResponseWithContainIds)(unsafe.Pointer(response.ContainerResponse))
 				fmt.Printf("[pod] receive AddPod responce")
 				fmt.Println(*responseWithContainIds)
-				fmt.Println(*responseWithContainIds.NetWorkInfos)
 				if responseWithContainIds.Err != nil {
 					//出错了
 					if p.SetStatusAndErr(POD_FAILED_STATUS, responseWithContainIds.Err) {
@@ -217,7 +237,8 @@ func (p *Pod) listeningResponse() {
 				} else {
 					//设置containersId
 					p.SetContainersAndStatus(responseWithContainIds.Containers, POD_RUNNING_STATUS)
-					p.setIpAddress(responseWithContainIds.NetWorkInfos)
+					p.configPod.Status.PodIP = responseWithContainIds.PodIP
+					p.startProbers()
 					p.uploadPod()
 				}
 				p.rwLock.Unlock()
@@ -231,22 +252,16 @@ func (p *Pod) listeningResponse() {
 					if responseWithProbeInfos.Err != nil {
 						p.SetStatusAndErr(POD_FAILED_STATUS, responseWithProbeInfos.Err)
 					} else {
-						status := POD_RUNNING_STATUS
-						for _, value := range responseWithProbeInfos.ProbeInfos {
-							if value == CONTAINER_CREATED_STATUS {
-								status = POD_CREATEED_STATUS
-								break
-							}
-							if value == CONTAINER_EXITED_STATUS {
-								status = POD_EXITED_STATUS
-								cli, _ := dockerClient.GetNewClient()
-								for _, val := range p.containers {
-									_ = cli.ContainerStop(context.Background(), val.ContainerId, nil)
-								}
-								break
+						before := p.copyContainerStatuses()
+						status := p.syncContainerStates(responseWithProbeInfos.States)
+						if status == POD_EXITED_STATUS || status == POD_SUCCEEDED_STATUS || status == POD_FAILED_STATUS {
+							for _, val := range p.containers {
+								_ = p.runtime.StopContainer(val.ContainerId)
 							}
 						}
-						if p.compareAndSetStatus(status) {
+						changed := p.compareAndSetStatus(status)
+						changed = !reflect.DeepEqual(before, p.configPod.Status.ContainerStatuses) || changed
+						if p.updateReadyCondition() || changed {
 							p.uploadPod()
 						}
 					}
@@ -254,6 +269,10 @@ func (p *Pod) listeningResponse() {
 					p.rwLock.Unlock()
 				}
 
+			case message.RESTART_CONTAINER, message.STOP_CONTAINER:
+				if response.ContainerResponse.Err != nil {
+					fmt.Println("[pod] restart or stop container error " + response.ContainerResponse.Err.Error())
+				}
 			case message.DELETE_POD:
 				return
 			}
@@ -322,9 +341,6 @@ func (p *Pod) SetContainersAndStatus(containers []object.ContainerMeta, status s
 	}
 	return p.compareAndSetStatus(status)
 }
-func (p *Pod) setIpAddress(settings *types.NetworkSettings) {
-	p.configPod.Status.PodIP = settings.IPAddress
-}
 func filterSingle(input string) string {
 	index := strings.Index(input, "/tcp")
 	return input[0:index]
@@ -345,24 +361,7 @@ func (p *Pod) StartProbe() {
 		for {
 			select {
 			case <-p.timer.C:
-				p.rwLock.Lock()
-				//这几种情况下不进行检查
-				if p.canProbeWork && p.getStatus() != POD_PENDING_STATUS && p.getStatus() != POD_FAILED_STATUS && p.getStatus() != POD_DELETED_STATUS && p.getStatus() != POD_EXITED_STATUS {
-					command := &message.CommandWithContainerIds{}
-					command.CommandType = message.COMMAND_PROBE_CONTAINER
-					var group []string
-					for _, value := range p.containers {
-						group = append(group, value.ContainerId)
-					}
-					command.ContainerIds = group
-					podCommand := message.PodCommand{
-						PodCommandType:   message.PROBE_POD,
-						ContainerCommand: &(command.Command),
-					}
-					p.commandChan <- podCommand
-					p.canProbeWork = false
-				}
-				p.rwLock.Unlock()
+				p.probe()
 			case stop := <-p.stopChan:
 				if stop {
 					return
@@ -372,6 +371,28 @@ func (p *Pod) StartProbe() {
 	}(p)
 }
 
+// probe asks the worker for the states of the containers, unless the last probe is not answered yet
+func (p *Pod) probe() {
+	p.rwLock.Lock()
+	defer p.rwLock.Unlock()
+	//这几种情况下不进行检查
+	if p.canProbeWork && p.getStatus() != POD_PENDING_STATUS && p.getStatus() != POD_FAILED_STATUS && p.getStatus() != POD_DELETED_STATUS && p.getStatus() != POD_EXITED_STATUS && p.getStatus() != POD_SUCCEEDED_STATUS {
+		command := &message.CommandWithContainerIds{}
+		command.CommandType = message.COMMAND_PROBE_CONTAINER
+		var group []string
+		for _, value := range p.containers {
+			group = append(group, value.ContainerId)
+		}
+		command.ContainerIds = group
+		podCommand := message.PodCommand{
+			PodCommandType:   message.PROBE_POD,
+			ContainerCommand: &(command.Command),
+		}
+		p.commandChan <- podCommand
+		p.canProbeWork = false
+	}
+}
+
 func (p *Pod) DeletePod() {
 	p.rwLock.Lock()
 	p.compareAndSetStatus(POD_DELETED_STATUS)
@@ -387,7 +408,7 @@ func (p *Pod) DeletePod() {
 		ContainerCommand: &(command.Command),
 	}
 	p.commandChan <- podCommand
-	p.client.DeleteRuntimePod(p.GetName())
+	p.client.DeleteRuntimePod(p.GetNamespace(), p.GetName())
 	p.rwLock.Unlock()
 }
 
@@ -396,8 +417,145 @@ func (p *Pod) releaseResource() {
 	//拿下锁防止寄了
 	p.rwLock.Lock()
 	p.canProbeWork = false
+	if p.prober != nil {
+		p.prober.Stop()
+	}
+	p.stopBackoffs()
 	p.stopChan <- true
 	close(p.commandChan)
 	close(p.responseChan)
 	p.rwLock.Unlock()
 }
+
+// startProbers reports the containers just created and runs their probes, it is called with the lock held
+func (p *Pod) startProbers() {
+	p.prober = probe.NewManager(p.configPod.Status.PodIP, p.runtime)
+	p.configPod.Status.ContainerStatuses = nil
+	for index := range p.configPod.Spec.Containers {
+		container := &p.configPod.Spec.Containers[index]
+		// the first container is pause
+		meta := p.containers[index+1]
+		p.configPod.Status.ContainerStatuses = append(p.configPod.Status.ContainerStatuses, object.ContainerStatus{
+			Name:    meta.OriginName,
+			Started: container.StartupProbe == nil,
+			Ready:   container.StartupProbe == nil && container.ReadinessProbe == nil,
+		})
+		p.prober.AddContainer(meta.OriginName, meta.ContainerId, container)
+	}
+	p.updateReadyCondition()
+	go p.handleProbeUpdates(p.prober)
+}
+
+// handleProbeUpdates restarts the containers failing their liveness or startup probe,
+// and keeps the pod out of the services while a readiness probe fails
+func (p *Pod) handleProbeUpdates(prober *probe.Manager) {
+	for {
+		select {
+		case <-prober.Done():
+			return
+		case update := <-prober.Updates():
+			p.rwLock.Lock()
+			if p.getStatus() == POD_DELETED_STATUS {
+				p.rwLock.Unlock()
+				return
+			}
+			index := p.containerIndex(update.ContainerName)
+			if index == -1 {
+				p.rwLock.Unlock()
+				continue
+			}
+			fmt.Printf("[pod] %s probe of container %s: %s %s\n", update.ProbeType, update.ContainerName, update.Result, update.Message)
+			container := &p.configPod.Spec.Containers[index]
+			status := &p.configPod.Status.ContainerStatuses[index]
+			switch {
+			case update.Result == probe.Failure && update.ProbeType != probe.Readiness:
+				if p.restartPolicy() == object.RestartPolicyNever {
+					// the container is killed, the next check finds it terminated
+					p.stopContainer(index)
+				} else {
+					p.restartContainer(index, prober)
+				}
+			case update.ProbeType == probe.Startup:
+				status.Started = true
+				status.Ready = container.ReadinessProbe == nil
+			case update.ProbeType == probe.Readiness:
+				status.Ready = update.Result == probe.Success
+			}
+			p.updateReadyCondition()
+			p.uploadPod()
+			p.rwLock.Unlock()
+		}
+	}
+}
+
+// restartContainer restarts the container at index in the spec, it is called with the lock held
+func (p *Pod) restartContainer(index int, prober *probe.Manager) {
+	container := &p.configPod.Spec.Containers[index]
+	status := &p.configPod.Status.ContainerStatuses[index]
+	status.RestartCount++
+	status.Started = container.StartupProbe == nil
+	status.Ready = status.Started && container.ReadinessProbe == nil
+	command := &message.CommandWithId{}
+	command.CommandType = message.COMMAND_RESTART_CONTAINER
+	command.ContainerId = p.containers[index+1].ContainerId
+	p.commandChan <- message.PodCommand{
+		PodCommandType:   message.RESTART_CONTAINER,
+		ContainerCommand: &(command.Command),
+	}
+	if prober != nil {
+		prober.ContainerRestarted(status.Name)
+	}
+}
+
+// stopContainer stops the container at index in the spec, it is called with the lock held
+func (p *Pod) stopContainer(index int) {
+	p.configPod.Status.ContainerStatuses[index].Ready = false
+	command := &message.CommandWithId{}
+	command.CommandType = message.COMMAND_STOP_CONTAINER
+	command.ContainerId = p.containers[index+1].ContainerId
+	p.commandChan <- message.PodCommand{
+		PodCommandType:   message.STOP_CONTAINER,
+		ContainerCommand: &(command.Command),
+	}
+}
+
+func (p *Pod) copyContainerStatuses() []object.ContainerStatus {
+	return append([]object.ContainerStatus(nil), p.configPod.Status.ContainerStatuses...)
+}
+
+// containerIndex returns the index in the spec of the container named originName, -1 if there is none
+func (p *Pod) containerIndex(originName string) int {
+	for index := range p.configPod.Status.ContainerStatuses {
+		if p.configPod.Status.ContainerStatuses[index].Name == originName {
+			return index
+		}
+	}
+	return -1
+}
+
+// updateReadyCondition sets the Ready condition of the pod from its phase and its containers,
+// it returns true if the condition changed
+func (p *Pod) updateReadyCondition() bool {
+	status, reason := object.ConditionTrue, ""
+	if p.getStatus() != POD_RUNNING_STATUS {
+		status, reason = object.ConditionFalse, "PodNotRunning"
+	} else {
+		for _, container := range p.configPod.Status.ContainerStatuses {
+			if !container.Ready {
+				status, reason = object.ConditionFalse, "ContainersNotReady"
+				break
+			}
+		}
+	}
+	condition := p.configPod.GetCondition(object.PodReady)
+	if condition == nil {
+		p.configPod.Status.Conditions = append(p.configPod.Status.Conditions, object.PodCondition{Type: object.PodReady})
+		condition = &p.configPod.Status.Conditions[len(p.configPod.Status.Conditions)-1]
+	} else if condition.Status == status && condition.Reason == reason {
+		return false
+	}
+	condition.Status = status
+	condition.Reason = reason
+	condition.LastTransitionTime = time.Now()
+	return true
+}
@@ -0,0 +1,80 @@
+package pod
+
+import (
+	"gotest.tools/v3/assert"
+	"minik8s/object"
+	"minik8s/pkg/client"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (p *Pod) phaseIs(phase string) func() bool {
+	return func() bool {
+		p.rwLock.RLock()
+		defer p.rwLock.RUnlock()
+		return p.getStatus() == phase
+	}
+}
+
+func TestPodLifecycle(t *testing.T) {
+	apiserver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer apiserver.Close()
+	runtime := containerRuntime.NewFakeRuntime()
+	config := &object.Pod{
+		ObjectMeta: object.ObjectMeta{Name: "web"},
+		Spec: object.PodSpec{
+			RestartPolicy: object.RestartPolicyNever,
+			Containers:    []object.Container{{Name: "nginx", Image: "nginx"}},
+		},
+	}
+	p := NewPodfromConfig(config, client.Config{Host: strings.TrimPrefix(apiserver.URL, "http://")}, runtime)
+
+	waitFor(t, p.phaseIs(POD_RUNNING_STATUS))
+	assert.Equal(t, true, runtime.Images["nginx"])
+	pause, ok := runtime.Container("pause_web_nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, containerRuntime.ContainerRunning, pause.Status.State)
+	nginx, ok := runtime.Container("web_nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, containerRuntime.ContainerRunning, nginx.Status.State)
+	assert.Equal(t, pause.Status.Id, nginx.SandboxId)
+	p.rwLock.RLock()
+	assert.Equal(t, pause.Status.IPAddress, p.configPod.Status.PodIP)
+	assert.DeepEqual(t, []object.ContainerMeta{
+		{OriginName: "pause", RealName: "pause_web_nginx", ContainerId: pause.Status.Id},
+		{OriginName: "nginx", RealName: "web_nginx", ContainerId: nginx.Status.Id},
+	}, p.containers)
+	p.rwLock.RUnlock()
+
+	// Never leaves the failed container exited, and the pod fails
+	assert.NilError(t, runtime.Exit(nginx.Status.Id, 1))
+	p.probe()
+	waitFor(t, p.phaseIs(POD_FAILED_STATUS))
+	p.rwLock.RLock()
+	assert.Equal(t, int32(1), p.configPod.Status.ContainerStatuses[0].State.Terminated.ExitCode)
+	p.rwLock.RUnlock()
+	pause, _ = runtime.Container("pause_web_nginx")
+	assert.Equal(t, containerRuntime.ContainerExited, pause.Status.State)
+
+	p.DeletePod()
+	waitFor(t, func() bool {
+		_, ok := runtime.Container("pause_web_nginx")
+		return !ok
+	})
+	_, ok = runtime.Container("web_nginx")
+	assert.Assert(t, !ok)
+}
@@ -0,0 +1,158 @@
+package pod
+
+import (
+	"fmt"
+	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"time"
+)
+
+const (
+	// initialBackoff is how long an exited container waits before its first restart, it doubles at every crash
+	initialBackoff = 10 * time.Second
+	maxBackoff     = 5 * time.Minute
+	// backoffResetAfter is how long a container has to run for its backoff to start over
+	backoffResetAfter = 10 * time.Minute
+)
+
+// containerBackoff delays the restarts of a container crashing again and again
+type containerBackoff struct {
+	delay time.Duration
+	// timer restarts the container once the delay is over, pending until then
+	timer   *time.Timer
+	pending bool
+}
+
+func (p *Pod) restartPolicy() string {
+	if p.configPod.Spec.RestartPolicy == "" {
+		return object.RestartPolicyAlways
+	}
+	return p.configPod.Spec.RestartPolicy
+}
+
+func (p *Pod) shouldRestart(exitCode int32) bool {
+	switch p.restartPolicy() {
+	case object.RestartPolicyNever:
+		return false
+	case object.RestartPolicyOnFailure:
+		return exitCode != 0
+	}
+	return true
+}
+
+// syncContainerStates updates the status of the containers from their runtime states, states[0] is pause.
+// The exited containers the restart policy allows are restarted after their backoff.
+// It returns the phase of the pod, and is called with the lock held.
+func (p *Pod) syncContainerStates(states []containerRuntime.ContainerStatus) string {
+	if len(states) == 0 || states[0].State == CONTAINER_EXITED_STATUS {
+		// the containers share the network of pause, they cannot live without it
+		return POD_EXITED_STATUS
+	}
+	statuses := p.configPod.Status.ContainerStatuses
+	if len(statuses) != len(states)-1 {
+		return POD_RUNNING_STATUS
+	}
+	creating, active, failed := false, 0, false
+	for index := range statuses {
+		state := &states[index+1]
+		status := &statuses[index]
+		switch state.State {
+		case CONTAINER_CREATED_STATUS:
+			creating = true
+			active++
+			status.State = object.ContainerState{Waiting: &object.ContainerStateWaiting{Reason: object.ContainerReasonCreating}}
+		case CONTAINER_EXITED_STATUS, containerRuntime.ContainerDead:
+			terminated := terminatedState(state)
+			if !p.shouldRestart(terminated.ExitCode) {
+				status.State = object.ContainerState{Terminated: terminated}
+				status.Ready = false
+				failed = failed || terminated.ExitCode != 0
+				continue
+			}
+			active++
+			p.backOff(index, terminated)
+		default:
+			// running, and the transient restarting and paused
+			active++
+			status.State = object.ContainerState{Running: &object.ContainerStateRunning{StartedAt: state.StartedAt}}
+		}
+	}
+	switch {
+	case active == 0 && failed:
+		return POD_FAILED_STATUS
+	case active == 0:
+		return POD_SUCCEEDED_STATUS
+	case creating:
+		return POD_CREATEED_STATUS
+	}
+	return POD_RUNNING_STATUS
+}
+
+// backOff schedules the restart of the container at index which exited, unless it is already scheduled
+func (p *Pod) backOff(index int, terminated *object.ContainerStateTerminated) {
+	status := &p.configPod.Status.ContainerStatuses[index]
+	if p.backoffs == nil {
+		p.backoffs = make(map[string]*containerBackoff)
+	}
+	b, ok := p.backoffs[status.Name]
+	if ok && b.pending {
+		return
+	}
+	last := status.LastTerminationState.Terminated
+	if last != nil && last.FinishedAt.Equal(terminated.FinishedAt) && status.State.Waiting != nil {
+		// this exit has been handled already
+		return
+	}
+	if !ok || terminated.FinishedAt.Sub(terminated.StartedAt) >= backoffResetAfter {
+		b = &containerBackoff{delay: initialBackoff}
+		p.backoffs[status.Name] = b
+	} else {
+		b.delay *= 2
+		if b.delay > maxBackoff {
+			b.delay = maxBackoff
+		}
+	}
+	status.LastTerminationState = object.ContainerState{Terminated: terminated}
+	status.State = object.ContainerState{Waiting: &object.ContainerStateWaiting{
+		Reason:  object.ContainerReasonCrashLoopBackOff,
+		Message: fmt.Sprintf("back-off %s restarting failed container %s", b.delay, status.Name),
+	}}
+	status.Ready = false
+	b.pending = true
+	b.timer = time.AfterFunc(b.delay, func() {
+		p.rwLock.Lock()
+		defer p.rwLock.Unlock()
+		b.pending = false
+		if p.getStatus() == POD_DELETED_STATUS {
+			return
+		}
+		p.restartContainer(index, p.prober)
+		p.uploadPod()
+	})
+}
+
+// stopBackoffs cancels the pending restarts, it is called with the lock held
+func (p *Pod) stopBackoffs() {
+	for _, b := range p.backoffs {
+		if b.pending {
+			b.timer.Stop()
+			b.pending = false
+		}
+	}
+}
+
+func terminatedState(state *containerRuntime.ContainerStatus) *object.ContainerStateTerminated {
+	terminated := &object.ContainerStateTerminated{
+		ExitCode:   int32(state.ExitCode),
+		Reason:     object.ContainerReasonCompleted,
+		Message:    state.Error,
+		StartedAt:  state.StartedAt,
+		FinishedAt: state.FinishedAt,
+	}
+	if state.OOMKilled {
+		terminated.Reason = object.ContainerReasonOOMKilled
+	} else if state.ExitCode != 0 {
+		terminated.Reason = object.ContainerReasonError
+	}
+	return terminated
+}
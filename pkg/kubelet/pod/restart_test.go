@@ -0,0 +1,61 @@
+package pod
+
+import (
+	"gotest.tools/v3/assert"
+	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"testing"
+	"time"
+)
+
+func exited(exitCode int, startedAt time.Time, finishedAt time.Time) containerRuntime.ContainerStatus {
+	return containerRuntime.ContainerStatus{
+		State:      CONTAINER_EXITED_STATUS,
+		ExitCode:   exitCode,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+	}
+}
+
+func TestSyncContainerStates(t *testing.T) {
+	now := time.Now()
+	running := containerRuntime.ContainerStatus{State: CONTAINER_RUNNING_STATUS, StartedAt: now}
+	p := &Pod{configPod: &object.Pod{}}
+	p.configPod.Status.ContainerStatuses = []object.ContainerStatus{{Name: "web"}}
+	defer p.stopBackoffs()
+
+	assert.Equal(t, POD_RUNNING_STATUS, p.syncContainerStates([]containerRuntime.ContainerStatus{running, running}))
+	assert.Assert(t, p.configPod.Status.ContainerStatuses[0].State.Running != nil)
+
+	// Always restarts the container after a backoff
+	phase := p.syncContainerStates([]containerRuntime.ContainerStatus{running, exited(1, now, now.Add(time.Second))})
+	assert.Equal(t, POD_RUNNING_STATUS, phase)
+	status := p.configPod.Status.ContainerStatuses[0]
+	assert.Equal(t, object.ContainerReasonCrashLoopBackOff, status.State.Waiting.Reason)
+	assert.Equal(t, int32(1), status.LastTerminationState.Terminated.ExitCode)
+	assert.Equal(t, object.ContainerReasonError, status.LastTerminationState.Terminated.Reason)
+	assert.Equal(t, initialBackoff, p.backoffs["web"].delay)
+
+	// the next crash doubles the backoff, unless the container ran long enough
+	p.backoffs["web"].timer.Stop()
+	p.backoffs["web"].pending = false
+	p.syncContainerStates([]containerRuntime.ContainerStatus{running, exited(1, now, now.Add(2*time.Second))})
+	assert.Equal(t, 2*initialBackoff, p.backoffs["web"].delay)
+	p.backoffs["web"].timer.Stop()
+	p.backoffs["web"].pending = false
+	p.syncContainerStates([]containerRuntime.ContainerStatus{running, exited(1, now, now.Add(backoffResetAfter))})
+	assert.Equal(t, initialBackoff, p.backoffs["web"].delay)
+	p.stopBackoffs()
+
+	// OnFailure leaves the containers exiting with 0
+	p.configPod.Spec.RestartPolicy = object.RestartPolicyOnFailure
+	phase = p.syncContainerStates([]containerRuntime.ContainerStatus{running, exited(0, now, now)})
+	assert.Equal(t, POD_SUCCEEDED_STATUS, phase)
+	assert.Equal(t, object.ContainerReasonCompleted, p.configPod.Status.ContainerStatuses[0].State.Terminated.Reason)
+
+	p.configPod.Spec.RestartPolicy = object.RestartPolicyNever
+	phase = p.syncContainerStates([]containerRuntime.ContainerStatus{running, exited(137, now, now)})
+	assert.Equal(t, POD_FAILED_STATUS, phase)
+
+	assert.Equal(t, POD_EXITED_STATUS, p.syncContainerStates([]containerRuntime.ContainerStatus{exited(0, now, now), running}))
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"minik8s/object"
+	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
+	"minik8s/pkg/kubelet/containerRuntime"
 	"minik8s/pkg/kubelet/pod"
 	"sync"
 )
@@ -14,17 +16,18 @@ const PODMANAGER_TIME_INTERVAL = 20
 
 //存储所有的pod信息， 当需要获取pod信息时，直接从缓存中取，速度快  需要初始化变量
 type PodManager struct {
-	name2pod map[string]*pod.Pod //name-pod的映射
+	name2pod map[string]*pod.Pod //namespace/name-pod的映射
 	//对map的保护
 	lock         sync.Mutex
 	client       client.RESTClient
 	clientConfig client.Config
+	runtime      containerRuntime.ContainerRuntime
 	Err          error
 }
 
 var instance *PodManager
 
-func NewPodManager(clientConfig client.Config) *PodManager {
+func NewPodManager(clientConfig client.Config, runtime containerRuntime.ContainerRuntime) *PodManager {
 	newManager := &PodManager{}
 	newManager.name2pod = make(map[string]*pod.Pod)
 	restClient := client.RESTClient{
@@ -32,38 +35,48 @@ func NewPodManager(clientConfig client.Config) *PodManager {
 	}
 	newManager.client = restClient
 	newManager.clientConfig = clientConfig
+	newManager.runtime = runtime
 	var lock sync.Mutex
 	newManager.lock = lock
 	return newManager
 }
-func (p *PodManager) CheckIfPodExist(podName string) bool {
-	_, ok := p.name2pod[podName]
+func (p *PodManager) CheckIfPodExist(namespace string, podName string) bool {
+	_, ok := p.name2pod[config.NamespacedName(namespace, podName)]
 	return ok
 }
 
-func (p *PodManager) DeletePod(podName string) error {
+// GetPod returns the pod named podName in namespace if it runs on this node
+func (p *PodManager) GetPod(namespace string, podName string) (*pod.Pod, bool) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
-	if !p.CheckIfPodExist(podName) {
+	pod, ok := p.name2pod[config.NamespacedName(namespace, podName)]
+	return pod, ok
+}
+
+func (p *PodManager) DeletePod(namespace string, podName string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.CheckIfPodExist(namespace, podName) {
 		//不存在该pod
 		return errors.New(podName + "对应的pod不存在")
 	}
-	pod, _ := p.name2pod[podName]
+	key := config.NamespacedName(namespace, podName)
+	pod, _ := p.name2pod[key]
 	fmt.Printf("[DeleteRuntimePod] Prepare delete pod")
 	pod.DeletePod()
-	delete(p.name2pod, podName)
+	delete(p.name2pod, key)
 	return nil
 }
 
-func (p *PodManager) AddPod(config *object.Pod) error {
+func (p *PodManager) AddPod(podConfig *object.Pod) error {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 	//首先检查name对应的pod是否存在， 存在的话报错
-	if p.CheckIfPodExist(config.ObjectMeta.Name) {
-		return errors.New(config.ObjectMeta.Name + "对应的pod已经存在，请先删除原pod")
+	if p.CheckIfPodExist(podConfig.Namespace, podConfig.Name) {
+		return errors.New(podConfig.ObjectMeta.Name + "对应的pod已经存在，请先删除原pod")
 	}
-	newPod := pod.NewPodfromConfig(config, p.clientConfig)
-	p.name2pod[config.Name] = newPod
+	newPod := pod.NewPodfromConfig(podConfig, p.clientConfig, p.runtime)
+	p.name2pod[config.NamespacedName(podConfig.Namespace, podConfig.Name)] = newPod
 	return nil
 }
 
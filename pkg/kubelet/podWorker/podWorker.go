@@ -1,12 +1,19 @@
 package podWorker
 
 import (
-	"minik8s/pkg/kubelet/dockerClient"
+	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
 	"minik8s/pkg/kubelet/message"
+	"unsafe"
 )
 
-//用于Pod 与docker client之间的交互
+//用于Pod 与container runtime之间的交互
 type PodWorker struct {
+	runtime containerRuntime.ContainerRuntime
+}
+
+func NewPodWorker(runtime containerRuntime.ContainerRuntime) *PodWorker {
+	return &PodWorker{runtime: runtime}
 }
 
 func (podWorker *PodWorker) SyncLoop(commands <-chan message.PodCommand, responses chan<- message.PodResponse) {
@@ -16,7 +23,7 @@ func (podWorker *PodWorker) SyncLoop(commands <-chan message.PodCommand, respons
 			if !ok {
 				return
 			}
-			res := dockerClient.HandleCommand(command.ContainerCommand)
+			res := podWorker.HandleCommand(command.ContainerCommand)
 			result := message.PodResponse{
 				ContainerResponse: res,
 				PodResponseType:   command.PodCommandType,
@@ -25,3 +32,168 @@ func (podWorker *PodWorker) SyncLoop(commands <-chan message.PodCommand, respons
 		}
 	}
 }
+
+//涉及大量指针操作，要确保在caller和callee在同一个地址空间中
+func (podWorker *PodWorker) HandleCommand(command *message.Command) *message.Response {
+	switch command.CommandType {
+	case message.COMMAND_RUN_CONTAINER:
+		p := (*message.CommandWithId)(unsafe.Pointer(command))
+		var result message.Response
+		result.CommandType = message.COMMAND_RUN_CONTAINER
+		result.Err = podWorker.runtime.StartContainer(p.ContainerId)
+		return &result
+	case message.COMMAND_STOP_CONTAINER:
+		p := (*message.CommandWithId)(unsafe.Pointer(command))
+		var result message.Response
+		result.CommandType = message.COMMAND_STOP_CONTAINER
+		result.Err = podWorker.runtime.StopContainer(p.ContainerId)
+		return &result
+	case message.COMMAND_RESTART_CONTAINER:
+		p := (*message.CommandWithId)(unsafe.Pointer(command))
+		var result message.Response
+		result.CommandType = message.COMMAND_RESTART_CONTAINER
+		result.Err = podWorker.runtime.StopContainer(p.ContainerId)
+		if result.Err == nil {
+			result.Err = podWorker.runtime.StartContainer(p.ContainerId)
+		}
+		return &result
+	case message.COMMAND_BUILD_CONTAINERS_OF_POD:
+		p := (*message.CommandWithConfig)(unsafe.Pointer(command))
+		res, podIP, err := podWorker.createContainersOfPod(p.Group)
+		var result message.ResponseWithContainIds
+		result.Err = err
+		result.CommandType = message.COMMAND_BUILD_CONTAINERS_OF_POD
+		result.Containers = res
+		result.PodIP = podIP
+		return &(result.Response)
+	case message.COMMAND_PULL_IMAGES:
+		p := (*message.CommandWithImages)(unsafe.Pointer(command))
+		var result message.Response
+		result.CommandType = message.COMMAND_PULL_IMAGES
+		result.Err = podWorker.runtime.PullImages(p.Images)
+		return &result
+	case message.COMMAND_PROBE_CONTAINER:
+		p := (*message.CommandWithContainerIds)(unsafe.Pointer(command))
+		res, states, err := podWorker.probeContainers(p.ContainerIds)
+		var result message.ResponseWithProbeInfos
+		result.Err = err
+		result.CommandType = message.COMMAND_PROBE_CONTAINER
+		result.ProbeInfos = res
+		result.States = states
+		return &(result.Response)
+	case message.COMMAND_DELETE_CONTAINER:
+		//删除containers的操作
+		p := (*message.CommandWithContainerIds)(unsafe.Pointer(command))
+		var result message.Response
+		result.CommandType = message.COMMAND_DELETE_CONTAINER
+		result.Err = podWorker.deleteContainers(p.ContainerIds)
+		return &result
+	}
+	return nil
+}
+
+// createContainersOfPod creates the sandbox and the containers of a pod and starts them,
+// it returns the containers, pause first, and the ip of the pod
+func (podWorker *PodWorker) createContainersOfPod(containers []object.Container) ([]object.ContainerMeta, string, error) {
+	var result []object.ContainerMeta
+	//先生成所有要暴露的port集合
+	var totlePort []object.Port
+	var images []string
+	//防止重名，先检查是否重名，有的话删除
+	var names []string
+	pauseName := "pause"
+	for _, value := range containers {
+		pauseName += "_" + value.Name
+		names = append(names, value.Name)
+		images = append(images, value.Image)
+		totlePort = append(totlePort, value.Ports...)
+	}
+	names = append(names, pauseName)
+	err := podWorker.deleteExitedContainers(names)
+	if err != nil {
+		return nil, "", err
+	}
+	//先统一拉取镜像
+	err = podWorker.runtime.PullImages(images)
+	if err != nil {
+		return nil, "", err
+	}
+	//创建pause容器
+	sandboxId, err := podWorker.runtime.CreateSandbox(pauseName, totlePort)
+	if err != nil {
+		return nil, "", err
+	}
+	result = append(result, object.ContainerMeta{
+		RealName:    pauseName,
+		ContainerId: sandboxId,
+	})
+	for index := range containers {
+		id, err := podWorker.runtime.CreateContainer(sandboxId, &containers[index])
+		if err != nil {
+			return nil, "", err
+		}
+		result = append(result, object.ContainerMeta{
+			RealName:    containers[index].Name,
+			ContainerId: id,
+		})
+	}
+	//启动容器
+	for _, value := range result {
+		err = podWorker.runtime.StartContainer(value.ContainerId)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	sandbox, err := podWorker.runtime.InspectContainer(sandboxId)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, sandbox.IPAddress, nil
+}
+
+//检查容器状态
+func (podWorker *PodWorker) probeContainers(containerIds []string) ([]string, []containerRuntime.ContainerStatus, error) {
+	var res []string
+	var states []containerRuntime.ContainerStatus
+	for _, value := range containerIds {
+		status, err := podWorker.runtime.InspectContainer(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		res = append(res, status.State)
+		states = append(states, *status)
+	}
+	return res, states, nil
+}
+
+//删除containers
+func (podWorker *PodWorker) deleteContainers(containerIds []string) error {
+	//需要先停止containers
+	for _, value := range containerIds {
+		err := podWorker.runtime.StopContainer(value)
+		if err != nil {
+			return err
+		}
+	}
+	for _, value := range containerIds {
+		err := podWorker.runtime.RemoveContainer(value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//查找是否存在，存在就删除
+func (podWorker *PodWorker) deleteExitedContainers(names []string) error {
+	for _, value := range names {
+		_, err := podWorker.runtime.InspectContainer(value)
+		if err == nil {
+			err = podWorker.runtime.RemoveContainer(value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package probe
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"sync"
+	"time"
+)
+
+type ProbeType int
+
+const (
+	Liveness ProbeType = iota
+	Readiness
+	Startup
+)
+
+func (t ProbeType) String() string {
+	switch t {
+	case Liveness:
+		return "Liveness"
+	case Readiness:
+		return "Readiness"
+	}
+	return "Startup"
+}
+
+// Update is sent when the result of a probe reaches its threshold
+type Update struct {
+	ContainerName string
+	ProbeType     ProbeType
+	Result        Result
+	Message       string
+}
+
+// Manager runs the probes of the containers of a pod, one worker per probe
+type Manager struct {
+	podIP   string
+	runtime containerRuntime.ContainerRuntime
+	updates chan Update
+	stopCh  chan struct{}
+
+	mtx     sync.Mutex
+	workers map[string][]*worker
+	// started are the containers whose startup probe succeeded, or which have none
+	started map[string]bool
+}
+
+func NewManager(podIP string, runtime containerRuntime.ContainerRuntime) *Manager {
+	return &Manager{
+		podIP:   podIP,
+		runtime: runtime,
+		updates: make(chan Update, 10),
+		stopCh:  make(chan struct{}),
+		workers: make(map[string][]*worker),
+		started: make(map[string]bool),
+	}
+}
+
+// AddContainer starts the probes of the container
+func (m *Manager) AddContainer(name string, containerId string, container *object.Container) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.started[name] = container.StartupProbe == nil
+	probes := []struct {
+		probeType ProbeType
+		spec      *object.Probe
+	}{
+		{Startup, container.StartupProbe},
+		{Liveness, container.LivenessProbe},
+		{Readiness, container.ReadinessProbe},
+	}
+	for _, p := range probes {
+		if p.spec == nil {
+			continue
+		}
+		w := newWorker(m, p.probeType, p.spec, name, containerId)
+		m.workers[name] = append(m.workers[name], w)
+		go w.run()
+	}
+}
+
+// ContainerRestarted starts the probes of the container over, the startup probe first
+func (m *Manager) ContainerRestarted(name string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for _, w := range m.workers[name] {
+		if w.probeType == Startup {
+			m.started[name] = false
+		}
+		w.reset()
+	}
+}
+
+// Updates receives the results of the probes until the manager is stopped
+func (m *Manager) Updates() <-chan Update {
+	return m.updates
+}
+
+// Done is closed when the manager is stopped
+func (m *Manager) Done() <-chan struct{} {
+	return m.stopCh
+}
+
+// Stop stops all the probes
+func (m *Manager) Stop() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	select {
+	case <-m.stopCh:
+	default:
+		close(m.stopCh)
+	}
+}
+
+func (m *Manager) isStarted(name string) bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.started[name]
+}
+
+func (m *Manager) setStarted(name string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.started[name] = true
+}
+
+func (m *Manager) send(update Update) {
+	select {
+	case m.updates <- update:
+	case <-m.stopCh:
+	}
+}
+
+// worker runs one probe of a container every period
+type worker struct {
+	manager       *Manager
+	probeType     ProbeType
+	spec          *object.Probe
+	containerName string
+	containerId   string
+	resetCh       chan struct{}
+
+	// lastResult has been returned resultRun times in a row
+	lastResult Result
+	resultRun  int32
+}
+
+func newWorker(m *Manager, probeType ProbeType, spec *object.Probe, containerName string, containerId string) *worker {
+	return &worker{
+		manager:       m,
+		probeType:     probeType,
+		spec:          spec,
+		containerName: containerName,
+		containerId:   containerId,
+		resetCh:       make(chan struct{}, 1),
+	}
+}
+
+func (w *worker) reset() {
+	select {
+	case w.resetCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *worker) run() {
+	period := time.Duration(orDefault(w.spec.PeriodSeconds, defaultPeriodSeconds)) * time.Second
+	initialDelay := time.Duration(w.spec.InitialDelaySeconds) * time.Second
+	timer := time.NewTimer(initialDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-w.manager.stopCh:
+			return
+		case <-w.resetCh:
+			w.lastResult, w.resultRun = Unknown, 0
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(initialDelay)
+		case <-timer.C:
+			w.doProbe()
+			timer.Reset(period)
+		}
+	}
+}
+
+func (w *worker) doProbe() {
+	started := w.manager.isStarted(w.containerName)
+	// the startup probe runs until it succeeds, the others wait for it
+	if started == (w.probeType == Startup) {
+		return
+	}
+	result, message := RunProbe(w.manager.runtime, w.spec, w.manager.podIP, w.containerId)
+	if result == Unknown {
+		return
+	}
+	if result == w.lastResult {
+		w.resultRun++
+	} else {
+		w.lastResult, w.resultRun = result, 1
+	}
+	threshold := orDefault(w.spec.FailureThreshold, defaultFailureThreshold)
+	if result == Success {
+		threshold = orDefault(w.spec.SuccessThreshold, defaultSuccessThreshold)
+		// liveness and startup probes are successful at the first success
+		if w.probeType != Readiness {
+			threshold = 1
+		}
+	}
+	if w.resultRun != threshold {
+		return
+	}
+	if w.probeType == Startup && result == Success {
+		w.manager.setStarted(w.containerName)
+	}
+	if w.probeType != Readiness && result == Failure {
+		// the container is restarted, it has to fail the whole threshold again
+		w.resultRun = 0
+	}
+	w.manager.send(Update{
+		ContainerName: w.containerName,
+		ProbeType:     w.probeType,
+		Result:        result,
+		Message:       message,
+	})
+}
@@ -1,4 +1,122 @@
 package probe
 
-type Probe struct {
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Result int
+
+const (
+	Unknown Result = iota
+	Success
+	Failure
+)
+
+func (r Result) String() string {
+	switch r {
+	case Success:
+		return "Success"
+	case Failure:
+		return "Failure"
+	}
+	return "Unknown"
+}
+
+// the defaults of the unset fields of a probe
+const (
+	defaultTimeoutSeconds   = 1
+	defaultPeriodSeconds    = 10
+	defaultSuccessThreshold = 1
+	defaultFailureThreshold = 3
+)
+
+// RunProbe runs the check of p once against the container, host is the ip of the pod
+func RunProbe(runtime containerRuntime.ContainerRuntime, p *object.Probe, host string, containerId string) (Result, string) {
+	timeout := time.Duration(orDefault(p.TimeoutSeconds, defaultTimeoutSeconds)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	switch {
+	case p.Exec != nil:
+		return runExec(ctx, runtime, p.Exec, containerId)
+	case p.HTTPGet != nil:
+		return runHTTPGet(ctx, p.HTTPGet, host)
+	case p.TCPSocket != nil:
+		return runTCPSocket(ctx, p.TCPSocket, host)
+	}
+	return Unknown, "probe has no handler"
+}
+
+func runExec(ctx context.Context, runtime containerRuntime.ContainerRuntime, action *object.ExecAction, containerId string) (Result, string) {
+	exitCode, output, err := runtime.ExecInContainer(ctx, containerId, action.Command)
+	if err != nil {
+		return Failure, err.Error()
+	}
+	if exitCode != 0 {
+		return Failure, fmt.Sprintf("command %q exited with %d: %s", strings.Join(action.Command, " "), exitCode, output)
+	}
+	return Success, output
+}
+
+func runHTTPGet(ctx context.Context, action *object.HTTPGetAction, host string) (Result, string) {
+	if action.Host != "" {
+		host = action.Host
+	}
+	scheme := "http"
+	if strings.EqualFold(action.Scheme, "HTTPS") {
+		scheme = "https"
+	}
+	path := action.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	url := scheme + "://" + net.JoinHostPort(host, strconv.Itoa(action.Port)) + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Failure, err.Error()
+	}
+	for _, header := range action.HTTPHeaders {
+		req.Header.Add(header.Name, header.Value)
+	}
+	httpClient := &http.Client{
+		// as kubernetes does, the certificate of the container is not verified
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Failure, err.Error()
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return Failure, fmt.Sprintf("HTTP probe failed with statuscode: %d", resp.StatusCode)
+	}
+	return Success, ""
+}
+
+func runTCPSocket(ctx context.Context, action *object.TCPSocketAction, host string) (Result, string) {
+	if action.Host != "" {
+		host = action.Host
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(action.Port)))
+	if err != nil {
+		return Failure, err.Error()
+	}
+	_ = conn.Close()
+	return Success, ""
+}
+
+func orDefault(value int32, def int32) int32 {
+	if value <= 0 {
+		return def
+	}
+	return value
 }
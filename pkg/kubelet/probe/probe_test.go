@@ -0,0 +1,69 @@
+package probe
+
+import (
+	"gotest.tools/v3/assert"
+	"minik8s/object"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestRunProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NilError(t, err)
+	portNumber, _ := strconv.Atoi(port)
+
+	p := &object.Probe{ProbeHandler: object.ProbeHandler{HTTPGet: &object.HTTPGetAction{Path: "healthz", Port: portNumber}}}
+	result, _ := RunProbe(nil, p, host, "")
+	assert.Equal(t, Success, result)
+	p.HTTPGet.Path = "/broken"
+	result, message := RunProbe(nil, p, host, "")
+	assert.Equal(t, Failure, result)
+	assert.Equal(t, "HTTP probe failed with statuscode: 500", message)
+
+	p = &object.Probe{ProbeHandler: object.ProbeHandler{TCPSocket: &object.TCPSocketAction{Port: portNumber}}}
+	result, _ = RunProbe(nil, p, host, "")
+	assert.Equal(t, Success, result)
+	server.Close()
+	result, _ = RunProbe(nil, p, host, "")
+	assert.Equal(t, Failure, result)
+
+	result, _ = RunProbe(nil, &object.Probe{}, host, "")
+	assert.Equal(t, Unknown, result)
+}
+
+func TestRunExecProbe(t *testing.T) {
+	runtime := containerRuntime.NewFakeRuntime()
+	runtime.ExecFunc = func(containerId string, cmd []string) (int, string, error) {
+		if cmd[0] == "healthy" {
+			return 0, "", nil
+		}
+		return 1, "unhealthy", nil
+	}
+	sandboxId, err := runtime.CreateSandbox("pause_web", nil)
+	assert.NilError(t, err)
+	assert.NilError(t, runtime.PullImages([]string{"nginx"}))
+	id, err := runtime.CreateContainer(sandboxId, &object.Container{Name: "web", Image: "nginx"})
+	assert.NilError(t, err)
+
+	p := &object.Probe{ProbeHandler: object.ProbeHandler{Exec: &object.ExecAction{Command: []string{"healthy"}}}}
+	// the container is not started yet
+	result, _ := RunProbe(runtime, p, "", id)
+	assert.Equal(t, Failure, result)
+	assert.NilError(t, runtime.StartContainer(id))
+	result, _ = RunProbe(runtime, p, "", id)
+	assert.Equal(t, Success, result)
+	p.Exec.Command = []string{"broken"}
+	result, message := RunProbe(runtime, p, "", id)
+	assert.Equal(t, Failure, result)
+	assert.Equal(t, `command "broken" exited with 1: unhealthy`, message)
+}
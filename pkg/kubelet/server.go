@@ -0,0 +1,132 @@
+package kubelet
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"io"
+	"minik8s/pkg/apiserver/config"
+	"minik8s/pkg/klog"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"minik8s/pkg/kubelet/pod"
+	"minik8s/pkg/kubelet/stream"
+	"net/http"
+	"strconv"
+)
+
+// serve runs the http api the apiserver proxies the requests about the containers of the pods to
+func (kl *Kubelet) serve() {
+	engine := gin.Default()
+	engine.GET(config.ContainerLogs, kl.containerLogs)
+	engine.GET(config.ContainerExec, kl.containerExec)
+	engine.GET(config.PortForward, kl.portForward)
+	err := engine.Run(fmt.Sprintf(":%d", config.KubeletPort))
+	if err != nil {
+		klog.Errorf("kubelet server stopped: %s\n", err.Error())
+	}
+}
+
+// podOf returns the pod in the path, or writes that it is not found
+func (kl *Kubelet) podOf(ctx *gin.Context) (*pod.Pod, bool) {
+	namespace := ctx.Param(config.ParamNamespace)
+	name := ctx.Param(config.ParamResourceName)
+	p, ok := kl.podManager.GetPod(namespace, name)
+	if !ok {
+		ctx.String(http.StatusNotFound, "pod %s not found on this node", config.NamespacedName(namespace, name))
+	}
+	return p, ok
+}
+
+// containerLogs writes the logs of a container of a pod, the query has
+// container, follow, tailLines, previous and timestamps as kubectl logs does
+func (kl *Kubelet) containerLogs(ctx *gin.Context) {
+	p, ok := kl.podOf(ctx)
+	if !ok {
+		return
+	}
+	options := containerRuntime.LogOptions{
+		Follow:     ctx.Query("follow") == "true",
+		Timestamps: ctx.Query("timestamps") == "true",
+	}
+	if tail := ctx.Query("tailLines"); tail != "" {
+		tailLines, err := strconv.ParseInt(tail, 10, 64)
+		if err != nil || tailLines < 0 {
+			ctx.String(http.StatusBadRequest, "invalid tailLines %s", tail)
+			return
+		}
+		options.TailLines = &tailLines
+	}
+	logs, err := p.ContainerLogs(ctx.Request.Context(), ctx.Query("container"), ctx.Query("previous") == "true", options)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	defer logs.Close()
+	ctx.Header("Content-Type", "text/plain; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	buf := make([]byte, 4096)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if _, werr := ctx.Writer.Write(buf[:n]); werr != nil {
+				return
+			}
+			// followed logs are sent as soon as they are written
+			ctx.Writer.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// containerExec runs the command of the query in a container of a pod over a websocket,
+// the query has container, command once per argument, stdin and tty as kubectl exec does
+func (kl *Kubelet) containerExec(ctx *gin.Context) {
+	p, ok := kl.podOf(ctx)
+	if !ok {
+		return
+	}
+	cmd := ctx.QueryArray("command")
+	if len(cmd) == 0 {
+		ctx.String(http.StatusBadRequest, "you must specify at least one command for the container")
+		return
+	}
+	container := ctx.Query("container")
+	tty := ctx.Query("tty") == "true"
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		stream.ServeExec(ws, ctx.Query("stdin") == "true", func(stdin io.Reader, stdout io.Writer, stderr io.Writer, resize <-chan containerRuntime.TerminalSize) (int, error) {
+			return p.Exec(ctx.Request.Context(), container, containerRuntime.ExecOptions{
+				Cmd:    cmd,
+				Tty:    tty,
+				Stdin:  stdin,
+				Stdout: stdout,
+				Stderr: stderr,
+				Resize: resize,
+			})
+		})
+	}}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
+
+// portForward connects a websocket to the port of the query in the network of a pod
+func (kl *Kubelet) portForward(ctx *gin.Context) {
+	p, ok := kl.podOf(ctx)
+	if !ok {
+		return
+	}
+	port, err := strconv.ParseInt(ctx.Query("port"), 10, 32)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, "invalid port %s", ctx.Query("port"))
+		return
+	}
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		ws.PayloadType = websocket.BinaryFrame
+		err := p.PortForward(ctx.Request.Context(), int32(port), ws)
+		if err != nil {
+			klog.Errorf("port forward to pod %s fail: %s\n", p.GetName(), err.Error())
+			ws.Close()
+		}
+	}}
+	server.ServeHTTP(ctx.Writer, ctx.Request)
+}
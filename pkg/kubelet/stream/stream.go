@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang.org/x/net/websocket"
+	"io"
+	"minik8s/pkg/kubelet/containerRuntime"
+)
+
+// An exec runs over a websocket, every binary message starts with the byte of its channel
+const (
+	StdinChannel byte = iota
+	StdoutChannel
+	StderrChannel
+	// StatusChannel carries the ExecStatus once the command exited, the last message of the server
+	StatusChannel
+	// ResizeChannel carries the new containerRuntime.TerminalSize of the client
+	ResizeChannel
+)
+
+// ExecStatus is how the command ended, Message is set if it could not run
+type ExecStatus struct {
+	ExitCode int    `json:"exitCode"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Executor runs a command with its streams, stdin is nil if the client sends none
+type Executor func(stdin io.Reader, stdout io.Writer, stderr io.Writer, resize <-chan containerRuntime.TerminalSize) (int, error)
+
+type channelWriter struct {
+	ws      *websocket.Conn
+	channel byte
+}
+
+func (w *channelWriter) Write(p []byte) (int, error) {
+	msg := make([]byte, len(p)+1)
+	msg[0] = w.channel
+	copy(msg[1:], p)
+	if err := websocket.Message.Send(w.ws, msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ServeExec runs exec with the streams the client sends over ws, then sends the status and closes ws
+func ServeExec(ws *websocket.Conn, withStdin bool, exec Executor) {
+	defer ws.Close()
+	var stdin io.Reader
+	stdinReader, stdinWriter := io.Pipe()
+	if withStdin {
+		stdin = stdinReader
+	}
+	resize := make(chan containerRuntime.TerminalSize, 4)
+	go func() {
+		defer close(resize)
+		defer stdinWriter.Close()
+		for {
+			var msg []byte
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			if len(msg) == 0 {
+				continue
+			}
+			switch msg[0] {
+			case StdinChannel:
+				// an empty message closes stdin
+				if len(msg) == 1 {
+					stdinWriter.Close()
+				} else if _, err := stdinWriter.Write(msg[1:]); err != nil && err != io.ErrClosedPipe {
+					return
+				}
+			case ResizeChannel:
+				size := containerRuntime.TerminalSize{}
+				if json.Unmarshal(msg[1:], &size) != nil {
+					continue
+				}
+				select {
+				case resize <- size:
+				default:
+				}
+			}
+		}
+	}()
+	exitCode, err := exec(stdin, &channelWriter{ws: ws, channel: StdoutChannel}, &channelWriter{ws: ws, channel: StderrChannel}, resize)
+	// the command reads no more, the client may still be writing
+	stdinReader.Close()
+	status := ExecStatus{ExitCode: exitCode}
+	if err != nil {
+		status.Message = err.Error()
+	}
+	SendStatus(ws, status)
+}
+
+// SendStatus ends the exec with status, for the errors before the command runs
+func SendStatus(ws *websocket.Conn, status ExecStatus) {
+	raw, _ := json.Marshal(status)
+	_ = websocket.Message.Send(ws, append([]byte{StatusChannel}, raw...))
+}
+
+// Exec sends stdin and the sizes of the terminal to the command the server runs and writes its output,
+// until the status of the command comes. stdin and resize may be nil.
+func Exec(ws *websocket.Conn, stdin io.Reader, stdout io.Writer, stderr io.Writer, resize <-chan containerRuntime.TerminalSize) (*ExecStatus, error) {
+	if stdin != nil {
+		go func() {
+			buf := make([]byte, 4096)
+			for {
+				n, err := stdin.Read(buf)
+				if n > 0 {
+					if websocket.Message.Send(ws, append([]byte{StdinChannel}, buf[:n]...)) != nil {
+						return
+					}
+				}
+				if err != nil {
+					_ = websocket.Message.Send(ws, []byte{StdinChannel})
+					return
+				}
+			}
+		}()
+	}
+	if resize != nil {
+		go func() {
+			for size := range resize {
+				raw, _ := json.Marshal(size)
+				if websocket.Message.Send(ws, append([]byte{ResizeChannel}, raw...)) != nil {
+					return
+				}
+			}
+		}()
+	}
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return nil, fmt.Errorf("connection lost before the command exited: %v", err)
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		switch msg[0] {
+		case StdoutChannel:
+			_, _ = stdout.Write(msg[1:])
+		case StderrChannel:
+			_, _ = stderr.Write(msg[1:])
+		case StatusChannel:
+			status := &ExecStatus{}
+			if err := json.Unmarshal(msg[1:], status); err != nil {
+				return nil, err
+			}
+			return status, nil
+		}
+	}
+}
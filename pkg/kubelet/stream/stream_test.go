@@ -0,0 +1,51 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"golang.org/x/net/websocket"
+	"gotest.tools/v3/assert"
+	"io"
+	"io/ioutil"
+	"minik8s/pkg/kubelet/containerRuntime"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	sizes := make(chan containerRuntime.TerminalSize, 1)
+	server := httptest.NewServer(websocket.Server{Handler: func(ws *websocket.Conn) {
+		ServeExec(ws, ws.Request().URL.Query().Get("stdin") == "true", func(stdin io.Reader, stdout io.Writer, stderr io.Writer, resize <-chan containerRuntime.TerminalSize) (int, error) {
+			if stdin == nil {
+				return 0, errors.New("no stdin")
+			}
+			input, _ := ioutil.ReadAll(stdin)
+			sizes <- <-resize
+			_, _ = stdout.Write(bytes.ToUpper(input))
+			_, _ = stderr.Write([]byte("done"))
+			return 3, nil
+		})
+	}})
+	defer server.Close()
+	wsUrl := strings.Replace(server.URL, "http", "ws", 1)
+
+	ws, err := websocket.Dial(wsUrl+"?stdin=true", "", server.URL)
+	assert.NilError(t, err)
+	resize := make(chan containerRuntime.TerminalSize, 1)
+	resize <- containerRuntime.TerminalSize{Width: 80, Height: 24}
+	var stdout, stderr bytes.Buffer
+	status, err := Exec(ws, strings.NewReader("hello"), &stdout, &stderr, resize)
+	assert.NilError(t, err)
+	assert.Equal(t, 3, status.ExitCode)
+	assert.Equal(t, "", status.Message)
+	assert.Equal(t, "HELLO", stdout.String())
+	assert.Equal(t, "done", stderr.String())
+	assert.Equal(t, containerRuntime.TerminalSize{Width: 80, Height: 24}, <-sizes)
+
+	ws, err = websocket.Dial(wsUrl, "", server.URL)
+	assert.NilError(t, err)
+	status, err = Exec(ws, nil, &stdout, &stderr, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, "no stdin", status.Message)
+}
@@ -0,0 +1,51 @@
+package kubelet
+
+import (
+	"fmt"
+	"minik8s/object"
+	"time"
+)
+
+// taintEvictionInterval is how often the kubelet checks the NoExecute taints of its node
+const taintEvictionInterval = 5 * time.Second
+
+// taintEviction evicts the pods of the node which do not tolerate its NoExecute taints,
+// the pods tolerating them for TolerationSeconds are evicted once the time is up
+func (kl *Kubelet) taintEviction() {
+	// when the pods were first seen on the node with a NoExecute taint they tolerate for a while
+	firstSeen := make(map[string]time.Time)
+	for {
+		select {
+		case <-kl.stopChannel:
+			return
+		case <-time.After(taintEvictionInterval):
+		}
+		dynamicIp := kl.kubeNetSupport.GetKubeproxySnapShoot().MyDynamicIp
+		node, err := kl.Client.GetNode(dynamicIp)
+		if err != nil || node == nil {
+			continue
+		}
+		seen := make(map[string]time.Time)
+		now := time.Now()
+		for key, pod := range kl.podManager.CopyName2pod() {
+			since, ok := firstSeen[key]
+			if !ok {
+				since = now
+			}
+			evictAt, evict := object.EvictionTime(pod.GetTolerations(), node.Spec.Taints, since)
+			if !evict {
+				continue
+			}
+			if now.Before(evictAt) {
+				seen[key] = since
+				continue
+			}
+			fmt.Printf("[taintEviction] evict pod %s from node %s\n", key, node.MetaData.Name)
+			err = kl.Client.DeleteConfigPod(pod.GetNamespace(), pod.GetName())
+			if err != nil {
+				fmt.Printf("[taintEviction] evict pod %s fail: %s\n", key, err.Error())
+			}
+		}
+		firstSeen = seen
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/streadway/amqp"
 	"io"
 	"minik8s/pkg/apiserver/app"
+	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/etcdstore"
 	"minik8s/pkg/klog"
 	"minik8s/pkg/messaging"
@@ -19,6 +20,10 @@ import (
 type WatchHandler func(res etcdstore.WatchRes)
 type CancelFunc func()
 
+// ErrResourceVersionTooOld is returned by WatchFrom when the events since the resource version are lost,
+// list again and watch from the resource version of the new list
+var ErrResourceVersionTooOld = errors.New("resource version too old, relist")
+
 type ListerWatcher struct {
 	subscriber *messaging.Subscriber
 	rootURL    string
@@ -37,29 +42,40 @@ func NewListerWatcher(c *Config) (*ListerWatcher, error) {
 }
 
 func (ls *ListerWatcher) List(key string) ([]etcdstore.ListRes, error) {
+	resList, _, err := ls.ListWithVersion(key)
+	return resList, err
+}
+
+// ListWithVersion is List which also returns the version to resume watching the listed key from
+func (ls *ListerWatcher) ListWithVersion(key string) ([]etcdstore.ListRes, int64, error) {
 	resourceURL := ls.rootURL + key
 	request, err := http.NewRequest("GET", resourceURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	response, err := http.DefaultClient.Do(request)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if response.StatusCode != http.StatusOK {
-		return nil, errors.New("StatusCode not 200")
+		return nil, 0, errors.New("StatusCode not 200")
 	}
 	reader := response.Body
 	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	var resList []etcdstore.ListRes
 	err = json.Unmarshal(data, &resList)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	return resList, nil
+	version, err := strconv.ParseInt(response.Header.Get(config.HeaderResourceVersion), 10, 64)
+	if err != nil {
+		// a single object is not listed at a revision of the store
+		version = ResourceVersion(resList)
+	}
+	return resList, version, nil
 }
 
 // Watch should never return until stopChannel is closed
@@ -194,3 +210,86 @@ func (ls *ListerWatcher) WatchNonBlocking(key string, handler WatchHandler) (Can
 		ls.subscriber.Unsubscribe(stop)
 	}, nil
 }
+
+// ResourceVersion returns the version to resume watching a listed prefix from.
+//
+// It is the latest version of the listed objects. The only events between it and the list
+// are deletions of objects absent from the list, which are harmless to replay.
+func ResourceVersion(resList []etcdstore.ListRes) int64 {
+	var version int64
+	for _, res := range resList {
+		if res.ResourceVersion > version {
+			version = res.ResourceVersion
+		}
+	}
+	return version
+}
+
+// WatchFrom is Watch resumed right after resourceVersion, usually the ResourceVersion of the last
+// event handled before reconnecting. The events since then are replayed before the new ones,
+// ErrResourceVersionTooOld is returned if they have been compacted.
+//
+// A resourceVersion of 0 watches from now on, the same as Watch.
+func (ls *ListerWatcher) WatchFrom(key string, resourceVersion int64, handler WatchHandler, stopChannel <-chan struct{}) error {
+	if resourceVersion == 0 {
+		return ls.Watch(key, handler, stopChannel)
+	}
+	// request the server to replay and publish
+	resourceURL := ls.rootURL + key
+	formData := url.Values{}
+	formData.Add(config.ParamResourceVersion, strconv.FormatInt(resourceVersion, 10))
+	response, err := http.DefaultClient.Post(resourceURL, "application/x-www-form-urlencoded", strings.NewReader(formData.Encode()))
+	if err != nil {
+		return err
+	}
+	if response.StatusCode == http.StatusGone {
+		return ErrResourceVersionTooOld
+	}
+	if response.StatusCode != http.StatusOK {
+		return errors.New("StatusCode not 200")
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	var t app.Ticket
+	err = json.Unmarshal(data, &t)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		formData := url.Values{}
+		klog.Infof("Closing ticket %d\n", t.T)
+		formData.Add("ticket", strconv.FormatUint(t.T, 10))
+		response, err := http.DefaultClient.Post(resourceURL, "application/x-www-form-urlencoded", strings.NewReader(formData.Encode()))
+		if err != nil {
+			klog.Errorf("Error [%s] closing the watch channel with ticket %d\n", err.Error(), t.T)
+		} else if response.StatusCode != http.StatusOK {
+			klog.Errorf("Status Code %d !\n", response.StatusCode)
+		}
+	}()
+
+	// the replayed events are kept in the queue of the ticket until it is consumed
+	stop := make(chan struct{})
+	amqpHandler := func(d amqp.Delivery) {
+		var res etcdstore.WatchRes
+		err := json.Unmarshal(d.Body, &res)
+		if err != nil {
+			klog.Errorf("Error [%s] unmarshalling data from amqp channel\n", err.Error())
+			return
+		}
+		handler(res)
+	}
+	err = ls.subscriber.SubscribeQueue(t.Queue, amqpHandler, stop)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		ls.subscriber.Unsubscribe(stop)
+	}()
+
+	<-stopChannel
+	return nil
+}
@@ -62,6 +62,7 @@ func (d *Router) watchRuntimeService(res etcdstore.WatchRes) {
 	defer d.mtx.Unlock()
 
 	if res.ResType == etcdstore.DELETE {
+		// svcName is namespace/name
 		svcName := strings.TrimPrefix(res.Key, config.ServicePrefix+"/")
 		fmt.Printf("[watchRuntimeService] delete svc:%v\n", svcName)
 		clusterIP, ok := d.svcMap[svcName]
@@ -81,7 +82,7 @@ func (d *Router) watchRuntimeService(res etcdstore.WatchRes) {
 		return
 	}
 
-	svcName := svc.MetaData.Name
+	svcName := config.NamespacedName(svc.MetaData.Namespace, svc.MetaData.Name)
 	clusterIP := svc.Spec.ClusterIp
 	d.svcMap[svcName] = clusterIP
 
@@ -112,7 +113,8 @@ func (d *Router) watchVirtualService(res etcdstore.WatchRes) {
 		fmt.Println("[watchVirtualService] Unmarshall fail")
 		return
 	}
-	svcName := vs.Spec.Host
+	// the host refers to a service in the same namespace as the virtual service
+	svcName := config.NamespacedName(vs.Namespace, vs.Spec.Host)
 	clusterIP, ok := d.svcMap[svcName]
 	if !ok {
 		fmt.Printf("[watchVirtualService] service not exist:%v\n", svcName)
@@ -77,6 +77,69 @@ func (p *Publisher) Publish(exchangeName string, body []byte, contentType string
 	return nil
 }
 
+/*
+DeclareQueue 声明一个与交换机同名的队列并绑定到该交换机上
+
+队列不会随着消费者的离开而删除，在没有消费者时发布的消息会保存在队列中，
+用于resume的watch，不再使用时需要调用DeleteQueue
+
+*/
+func (p *Publisher) DeclareQueue(name string) error {
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	err = ch.ExchangeDeclare(
+		name,
+		amqp.ExchangeFanout,
+		true,
+		false,
+		false,
+		false,
+		nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.QueueDeclare(
+		name,
+		false,
+		false,
+		false,
+		false,
+		nil)
+	if err != nil {
+		return err
+	}
+
+	return ch.QueueBind(
+		name,
+		name,
+		name,
+		false,
+		nil)
+}
+
+/*
+DeleteQueue 删除DeclareQueue声明的队列和交换机
+
+*/
+func (p *Publisher) DeleteQueue(name string) error {
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	_, err = ch.QueueDelete(name, false, false, false)
+	if err != nil {
+		return err
+	}
+	return ch.ExchangeDelete(name, false, false)
+}
+
 func (p *Publisher) CloseConnection() error {
 	p.mtxNormal.Lock()
 	p.normal = true
@@ -12,6 +12,7 @@ type HandleFunc func(d amqp.Delivery)
 
 type redo struct {
 	exchangeName string
+	queueName    string // queueName 不为空时为SubscribeQueue的订阅
 	handler      HandleFunc
 	stopCh       <-chan struct{}
 }
@@ -119,8 +120,28 @@ func (s *Subscriber) Subscribe(exchangeName string, handler HandleFunc, stopChan
 		return err
 	}
 
+	return s.consume(ch, queue.Name, redo{exchangeName: exchangeName, handler: handler, stopCh: stopChannelCh})
+}
+
+/*
+SubscribeQueue 与Subscribe相同，但是消费一个由Publisher.DeclareQueue声明的已有队列，
+队列中在订阅之前发布的消息也会被消费
+
+queueName 订阅的队列名称
+
+*/
+func (s *Subscriber) SubscribeQueue(queueName string, handler HandleFunc, stopChannelCh <-chan struct{}) error {
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return err
+	}
+	return s.consume(ch, queueName, redo{queueName: queueName, handler: handler, stopCh: stopChannelCh})
+}
+
+// consume 在channel上消费queueName队列，并记录redo log
+func (s *Subscriber) consume(ch *amqp.Channel, queueName string, r redo) error {
 	msgs, err := ch.Consume(
-		queue.Name, // queue
+		queueName,  // queue
 		"",         // consumer
 		true,       // auto-ack
 		false,      // exclusive
@@ -136,7 +157,7 @@ func (s *Subscriber) Subscribe(exchangeName string, handler HandleFunc, stopChan
 	s.mtxRedo.Lock()
 	index := s.nextSlot
 	s.nextSlot++
-	s.redoLogs[index] = redo{exchangeName: exchangeName, handler: handler, stopCh: stopChannelCh}
+	s.redoLogs[index] = r
 	s.mtxRedo.Unlock()
 
 	stop := func(amqpChannel *amqp.Channel, index int, stopChannelCh <-chan struct{}, stopConnectionCh <-chan *amqp.Error) {
@@ -163,8 +184,8 @@ func (s *Subscriber) Subscribe(exchangeName string, handler HandleFunc, stopChan
 
 	stopConnectionCh := make(chan *amqp.Error)
 	s.conn.NotifyClose(stopConnectionCh)
-	go stop(ch, index, stopChannelCh, stopConnectionCh)
-	go consumeLoop(msgs, handler)
+	go stop(ch, index, r.stopCh, stopConnectionCh)
+	go consumeLoop(msgs, r.handler)
 	return nil
 }
 
@@ -187,7 +208,12 @@ func (s *Subscriber) recover() {
 	s.nextSlot = 0
 	s.mtxRedo.Unlock()
 	for _, redo := range redoCopy {
-		err := s.Subscribe(redo.exchangeName, redo.handler, redo.stopCh)
+		var err error
+		if redo.queueName != "" {
+			err = s.SubscribeQueue(redo.queueName, redo.handler, redo.stopCh)
+		} else {
+			err = s.Subscribe(redo.exchangeName, redo.handler, redo.stopCh)
+		}
 		if err != nil {
 			klog.Errorf("Error subscribing while reconnecting!\n")
 		}
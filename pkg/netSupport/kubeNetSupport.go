@@ -8,6 +8,7 @@ import (
 	"minik8s/pkg/client"
 	"minik8s/pkg/etcdstore"
 	"minik8s/pkg/klog"
+	"minik8s/pkg/kubelet/nodeStatus"
 	"minik8s/pkg/listerwatcher"
 	"minik8s/pkg/netSupport/boot"
 	"minik8s/pkg/netSupport/netconfig"
@@ -115,6 +116,7 @@ func (k *KubeNetSupport) registerNode() error {
 				DynamicIp:     k.myDynamicIp,
 				NodeIpAndMask: k.myIpAndMask,
 			},
+			Status: nodeStatus.GetNodeStatus(object.NodeStatus{}),
 		}
 	} else {
 		node = &object.Node{
@@ -124,6 +126,7 @@ func (k *KubeNetSupport) registerNode() error {
 				DynamicIp:     k.myDynamicIp,
 				NodeIpAndMask: k.myIpAndMask,
 			},
+			Status: nodeStatus.GetNodeStatus(k.node.Status),
 		}
 	}
 	err := k.Client.PutWrap(attachURL, node)
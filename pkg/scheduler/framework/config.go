@@ -0,0 +1,41 @@
+package framework
+
+import (
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+)
+
+// Config selects the plugins of the scheduler, e.g.
+//
+//	plugins:
+//	  - name: NodeResourcesFit
+//	  - name: LeastAllocated
+//	    weight: 2
+//	  - name: SelectorSpread
+//	  - name: DefaultBinder
+//
+// Every plugin is called at each extension point it implements, in the order of the list.
+type Config struct {
+	Plugins []PluginConfig `json:"plugins" yaml:"plugins"`
+}
+
+type PluginConfig struct {
+	Name string `json:"name" yaml:"name"`
+	// Weight multiplies the scores of a ScorePlugin, 0 is 1
+	Weight int64             `json:"weight" yaml:"weight"`
+	Args   map[string]string `json:"args" yaml:"args"`
+}
+
+// LoadConfig reads a yaml config file
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	err = yaml.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
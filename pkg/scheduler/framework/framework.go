@@ -0,0 +1,165 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"minik8s/object"
+	"sort"
+	"strings"
+)
+
+type weightedScorePlugin struct {
+	ScorePlugin
+	weight int64
+}
+
+// Framework runs the plugins of a Config at every extension point
+type Framework struct {
+	preFilterPlugins []PreFilterPlugin
+	filterPlugins    []FilterPlugin
+	scorePlugins     []weightedScorePlugin
+	bindPlugins      []BindPlugin
+}
+
+// NewFramework builds the plugins of cfg from registry
+func NewFramework(registry Registry, cfg *Config, handle *Handle) (*Framework, error) {
+	f := &Framework{}
+	for _, pluginConfig := range cfg.Plugins {
+		factory, ok := registry[pluginConfig.Name]
+		if !ok {
+			return nil, fmt.Errorf("plugin %s is not registered", pluginConfig.Name)
+		}
+		plugin, err := factory(pluginConfig.Args, handle)
+		if err != nil {
+			return nil, fmt.Errorf("build plugin %s fail: %s", pluginConfig.Name, err.Error())
+		}
+		implemented := false
+		if p, ok := plugin.(PreFilterPlugin); ok {
+			f.preFilterPlugins = append(f.preFilterPlugins, p)
+			implemented = true
+		}
+		if p, ok := plugin.(FilterPlugin); ok {
+			f.filterPlugins = append(f.filterPlugins, p)
+			implemented = true
+		}
+		if p, ok := plugin.(ScorePlugin); ok {
+			if pluginConfig.Weight < 0 {
+				return nil, fmt.Errorf("weight of plugin %s is negative", pluginConfig.Name)
+			}
+			weight := pluginConfig.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			f.scorePlugins = append(f.scorePlugins, weightedScorePlugin{ScorePlugin: p, weight: weight})
+			implemented = true
+		}
+		if p, ok := plugin.(BindPlugin); ok {
+			f.bindPlugins = append(f.bindPlugins, p)
+			implemented = true
+		}
+		if !implemented {
+			return nil, fmt.Errorf("plugin %s implements no extension point", pluginConfig.Name)
+		}
+	}
+	if len(f.bindPlugins) == 0 {
+		return nil, errors.New("no bind plugin is configured")
+	}
+	return f, nil
+}
+
+// RunPreFilterPlugins returns the first error of the PreFilter plugins
+func (f *Framework) RunPreFilterPlugins(state *CycleState, pod *object.Pod) error {
+	for _, p := range f.preFilterPlugins {
+		if err := p.PreFilter(state, pod); err != nil {
+			return fmt.Errorf("%s: %s", p.Name(), err.Error())
+		}
+	}
+	return nil
+}
+
+// RunFilterPlugins returns the nodes every Filter plugin lets the pod run on,
+// and if there is none a message counting why the nodes were rejected
+func (f *Framework) RunFilterPlugins(state *CycleState, pod *object.Pod, nodes []*NodeInfo) ([]*NodeInfo, string) {
+	var feasible []*NodeInfo
+	reasonCount := make(map[string]int)
+	for _, nodeInfo := range nodes {
+		fits := true
+		for _, p := range f.filterPlugins {
+			if reason := p.Filter(state, pod, nodeInfo); reason != "" {
+				reasonCount[reason]++
+				fits = false
+				break
+			}
+		}
+		if fits {
+			feasible = append(feasible, nodeInfo)
+		}
+	}
+	if len(feasible) != 0 {
+		return feasible, ""
+	}
+	var reasons []string
+	for reason, count := range reasonCount {
+		reasons = append(reasons, fmt.Sprintf("%d %s", count, reason))
+	}
+	sort.Strings(reasons)
+	message := fmt.Sprintf("0/%d nodes are available", len(nodes))
+	if len(reasons) != 0 {
+		message += ": " + strings.Join(reasons, "; ")
+	}
+	return nil, message
+}
+
+// RunScorePlugins returns the weighted sum of the scores of every node
+func (f *Framework) RunScorePlugins(state *CycleState, pod *object.Pod, nodes []*NodeInfo) (NodeScoreList, error) {
+	total := make(NodeScoreList, len(nodes))
+	for i, nodeInfo := range nodes {
+		total[i].Name = nodeInfo.Node.MetaData.Name
+	}
+	for _, p := range f.scorePlugins {
+		scores := make(NodeScoreList, len(nodes))
+		for i, nodeInfo := range nodes {
+			scores[i] = NodeScore{Name: nodeInfo.Node.MetaData.Name, Score: p.Score(state, pod, nodeInfo)}
+		}
+		if normalizer, ok := p.ScorePlugin.(NormalizeScorePlugin); ok {
+			normalizer.NormalizeScore(state, pod, scores)
+		}
+		for i, score := range scores {
+			if score.Score < 0 || score.Score > MaxNodeScore {
+				return nil, fmt.Errorf("plugin %s scores node %s %d, out of [0, %d]", p.Name(), score.Name, score.Score, MaxNodeScore)
+			}
+			total[i].Score += score.Score * p.weight
+		}
+	}
+	return total, nil
+}
+
+// RunBindPlugins binds the pod with the first bind plugin which does not skip it
+func (f *Framework) RunBindPlugins(state *CycleState, pod *object.Pod, nodeName string) error {
+	for _, p := range f.bindPlugins {
+		err := p.Bind(state, pod, nodeName)
+		if errors.Is(err, ErrSkip) {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("no bind plugin bound pod %s", pod.Name)
+}
+
+// SelectHost returns the node with the highest score, a random one of them if they are several
+func SelectHost(scores NodeScoreList) string {
+	var best []string
+	var bestScore int64 = -1
+	for _, score := range scores {
+		if score.Score > bestScore {
+			best, bestScore = []string{score.Name}, score.Score
+		} else if score.Score == bestScore {
+			best = append(best, score.Name)
+		}
+	}
+	if len(best) == 0 {
+		return ""
+	}
+	return best[rand.Intn(len(best))]
+}
@@ -0,0 +1,163 @@
+package framework_test
+
+import (
+	"gotest.tools/v3/assert"
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/framework/plugins"
+	"testing"
+)
+
+func newNodeInfo(name string, cpu string, memory string) *framework.NodeInfo {
+	node := &object.Node{}
+	node.MetaData.Name = name
+	node.Status.Allocatable = object.Limit{Cpu: cpu, Memory: memory}
+	return framework.NewNodeInfo(node)
+}
+
+func newPod(name string, cpu string, labels map[string]string) *object.Pod {
+	pod := &object.Pod{}
+	pod.Name = name
+	pod.Labels = labels
+	pod.Spec.Containers = []object.Container{{Limits: object.Limit{Cpu: cpu}}}
+	return pod
+}
+
+func TestFramework(t *testing.T) {
+	cfg := &framework.Config{Plugins: []framework.PluginConfig{
+		{Name: plugins.NodeResourcesFitName},
+		{Name: plugins.LeastAllocatedName},
+		{Name: plugins.SelectorSpreadName, Weight: 2},
+		{Name: plugins.DefaultBinderName},
+	}}
+	fwk, err := framework.NewFramework(plugins.NewInTreeRegistry(), cfg, &framework.Handle{})
+	assert.NilError(t, err)
+
+	small := newNodeInfo("small", "1", "1Gi")
+	large := newNodeInfo("large", "4", "4G")
+	small.AddPod(newPod("web-1", "500m", map[string]string{"app": "web"}))
+	large.AddPod(newPod("web-2", "500m", map[string]string{"app": "web"}))
+	large.AddPod(newPod("web-3", "500m", map[string]string{"app": "web"}))
+
+	pod := newPod("web-4", "600m", map[string]string{"app": "web"})
+	state := framework.NewCycleState()
+	assert.NilError(t, fwk.RunPreFilterPlugins(state, pod))
+	feasible, _ := fwk.RunFilterPlugins(state, pod, []*framework.NodeInfo{small, large})
+	assert.Equal(t, 1, len(feasible))
+	assert.Equal(t, "large", feasible[0].Node.MetaData.Name)
+
+	pod = newPod("web-4", "100m", map[string]string{"app": "web"})
+	state = framework.NewCycleState()
+	assert.NilError(t, fwk.RunPreFilterPlugins(state, pod))
+	feasible, _ = fwk.RunFilterPlugins(state, pod, []*framework.NodeInfo{small, large})
+	scores, err := fwk.RunScorePlugins(state, pod, feasible)
+	assert.NilError(t, err)
+	// small: 70 least allocated and 2 * 50 spread, large: 86 and 0
+	assert.Equal(t, "small", framework.SelectHost(scores))
+
+	pod = newPod("big", "8", nil)
+	state = framework.NewCycleState()
+	assert.NilError(t, fwk.RunPreFilterPlugins(state, pod))
+	_, message := fwk.RunFilterPlugins(state, pod, []*framework.NodeInfo{small, large})
+	assert.Equal(t, "0/2 nodes are available: 2 Insufficient cpu", message)
+
+	_, err = framework.NewFramework(plugins.NewInTreeRegistry(), &framework.Config{Plugins: []framework.PluginConfig{{Name: "Unknown"}}}, &framework.Handle{})
+	assert.ErrorContains(t, err, "not registered")
+}
+
+func TestAffinity(t *testing.T) {
+	var snapshot []*framework.NodeInfo
+	handle := &framework.Handle{NodeInfos: func() []*framework.NodeInfo { return snapshot }}
+	cfg := &framework.Config{Plugins: []framework.PluginConfig{
+		{Name: plugins.NodeAffinityName},
+		{Name: plugins.InterPodAntiAffinityName},
+		{Name: plugins.DefaultBinderName},
+	}}
+	fwk, err := framework.NewFramework(plugins.NewInTreeRegistry(), cfg, handle)
+	assert.NilError(t, err)
+
+	node1 := newNodeInfo("node1", "", "")
+	node1.Node.MetaData.Labels = map[string]string{"disk": "ssd", "zone": "a"}
+	node2 := newNodeInfo("node2", "", "")
+	node2.Node.MetaData.Labels = map[string]string{"disk": "hdd", "zone": "a"}
+	node3 := newNodeInfo("node3", "", "")
+	node3.Node.MetaData.Labels = map[string]string{"zone": "b"}
+	node1.AddPod(newPod("db-1", "", map[string]string{"app": "db"}))
+	snapshot = []*framework.NodeInfo{node1, node2, node3}
+
+	schedule := func(pod *object.Pod) ([]*framework.NodeInfo, string) {
+		state := framework.NewCycleState()
+		assert.NilError(t, fwk.RunPreFilterPlugins(state, pod))
+		return fwk.RunFilterPlugins(state, pod, snapshot)
+	}
+
+	pod := newPod("web", "", nil)
+	pod.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	feasible, _ := schedule(pod)
+	assert.Equal(t, 1, len(feasible))
+	assert.Equal(t, "node1", feasible[0].Node.MetaData.Name)
+
+	pod.Spec.NodeSelector = nil
+	pod.Spec.Affinity = &object.Affinity{NodeAffinity: &object.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &object.NodeSelector{NodeSelectorTerms: []object.NodeSelectorTerm{
+			{MatchExpressions: []object.NodeSelectorRequirement{{Key: "disk", Operator: object.SelectorOpExists}}},
+		}},
+	}}
+	feasible, _ = schedule(pod)
+	assert.Equal(t, 2, len(feasible))
+
+	pod.Spec.Affinity = &object.Affinity{PodAntiAffinity: &object.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []object.PodAffinityTerm{
+			{LabelSelector: &object.LabelSelector{MatchLabels: map[string]string{"app": "db"}}, TopologyKey: "zone"},
+		},
+	}}
+	feasible, _ = schedule(pod)
+	assert.Equal(t, 1, len(feasible))
+	assert.Equal(t, "node3", feasible[0].Node.MetaData.Name)
+
+	pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey = object.LabelHostname
+	feasible, _ = schedule(pod)
+	assert.Equal(t, 2, len(feasible))
+
+	pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey = "disk"
+	pod.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	_, message := schedule(pod)
+	assert.Equal(t, "0/3 nodes are available: 1 node(s) didn't match pod anti-affinity rules; 2 node(s) didn't match Pod's node affinity/selector", message)
+}
+
+func TestTaintToleration(t *testing.T) {
+	cfg := &framework.Config{Plugins: []framework.PluginConfig{
+		{Name: plugins.TaintTolerationName},
+		{Name: plugins.DefaultBinderName},
+	}}
+	fwk, err := framework.NewFramework(plugins.NewInTreeRegistry(), cfg, &framework.Handle{})
+	assert.NilError(t, err)
+
+	master := newNodeInfo("master", "", "")
+	master.Node.Spec.Taints = []object.Taint{{Key: "role", Value: "master", Effect: object.TaintEffectNoSchedule}}
+	gpu := newNodeInfo("gpu", "", "")
+	gpu.Node.Spec.Taints = []object.Taint{{Key: "gpu", Effect: object.TaintEffectPreferNoSchedule}}
+	worker := newNodeInfo("worker", "", "")
+	nodes := []*framework.NodeInfo{master, gpu, worker}
+
+	pod := newPod("web", "", nil)
+	state := framework.NewCycleState()
+	feasible, _ := fwk.RunFilterPlugins(state, pod, nodes)
+	assert.Equal(t, 2, len(feasible))
+	scores, err := fwk.RunScorePlugins(state, pod, feasible)
+	assert.NilError(t, err)
+	assert.Equal(t, "worker", framework.SelectHost(scores))
+
+	pod.Spec.Tolerations = []object.Toleration{{Key: "role", Operator: object.TolerationOpEqual, Value: "master"}}
+	feasible, _ = fwk.RunFilterPlugins(state, pod, nodes)
+	assert.Equal(t, 3, len(feasible))
+
+	pod.Spec.Tolerations = []object.Toleration{{Key: "role", Value: "worker"}}
+	feasible, message := fwk.RunFilterPlugins(state, pod, []*framework.NodeInfo{master})
+	assert.Equal(t, 0, len(feasible))
+	assert.Equal(t, "0/1 nodes are available: 1 node(s) had untolerated taint {role: master}", message)
+
+	pod.Spec.Tolerations = []object.Toleration{{Operator: object.TolerationOpExists}}
+	feasible, _ = fwk.RunFilterPlugins(state, pod, nodes)
+	assert.Equal(t, 3, len(feasible))
+}
@@ -0,0 +1,90 @@
+package framework
+
+import (
+	"errors"
+	"minik8s/object"
+	"minik8s/pkg/client"
+)
+
+// MaxNodeScore is the score of a node which suits a pod best, the scores of a plugin are in [0, MaxNodeScore]
+const MaxNodeScore int64 = 100
+
+// ErrSkip is returned by a BindPlugin which leaves the pod to the next one
+var ErrSkip = errors.New("skip")
+
+// Plugin is the parent of every scheduling plugin, a plugin implements any of the extension points below
+// and is called at each of them.
+type Plugin interface {
+	Name() string
+}
+
+// PreFilterPlugin is called once per pod before filtering, it usually computes data for the other
+// extension points and writes it to the CycleState. An error makes the pod unschedulable.
+type PreFilterPlugin interface {
+	Plugin
+	PreFilter(state *CycleState, pod *object.Pod) error
+}
+
+// FilterPlugin rejects the nodes the pod cannot run on, it returns why the pod does not fit
+// on the node or "" if it fits.
+type FilterPlugin interface {
+	Plugin
+	Filter(state *CycleState, pod *object.Pod, nodeInfo *NodeInfo) string
+}
+
+// ScorePlugin ranks the nodes which passed the filters, the node with the highest weighted sum wins.
+// The scores must be in [0, MaxNodeScore] unless the plugin is also a NormalizeScorePlugin.
+type ScorePlugin interface {
+	Plugin
+	Score(state *CycleState, pod *object.Pod, nodeInfo *NodeInfo) int64
+}
+
+// NormalizeScorePlugin rescales the scores of all the nodes into [0, MaxNodeScore] once they are computed
+type NormalizeScorePlugin interface {
+	ScorePlugin
+	NormalizeScore(state *CycleState, pod *object.Pod, scores NodeScoreList)
+}
+
+// BindPlugin binds the pod to the selected node, the bind plugins are called in turn until one
+// does not return ErrSkip.
+type BindPlugin interface {
+	Plugin
+	Bind(state *CycleState, pod *object.Pod, nodeName string) error
+}
+
+// Handle gives the plugins access to the scheduler
+type Handle struct {
+	Client client.RESTClient
+	// NodeInfos returns every node of the cycle being run with its pods, for the plugins
+	// which look past the node they filter or score
+	NodeInfos func() []*NodeInfo
+}
+
+// PluginFactory builds a plugin, args are the ones set for it in the config file
+type PluginFactory func(args map[string]string, handle *Handle) (Plugin, error)
+
+// NodeScore is the score of a node
+type NodeScore struct {
+	Name  string
+	Score int64
+}
+
+type NodeScoreList []NodeScore
+
+// CycleState holds the data of the plugins while a pod is scheduled, it is not shared between pods
+type CycleState struct {
+	data map[string]interface{}
+}
+
+func NewCycleState() *CycleState {
+	return &CycleState{data: make(map[string]interface{})}
+}
+
+func (c *CycleState) Read(key string) (interface{}, bool) {
+	value, ok := c.data[key]
+	return value, ok
+}
+
+func (c *CycleState) Write(key string, value interface{}) {
+	c.data[key] = value
+}
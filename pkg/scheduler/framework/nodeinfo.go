@@ -0,0 +1,46 @@
+package framework
+
+import "minik8s/object"
+
+// Unknown is the allocatable amount of a node which does not report it
+const Unknown int64 = -1
+
+// Resource is an amount of cpu in millicores and of memory in bytes
+type Resource struct {
+	MilliCPU int64
+	Memory   int64
+}
+
+// NodeInfo is a node and the pods bound to it
+type NodeInfo struct {
+	Node        *object.Node
+	Pods        []*object.Pod
+	Allocatable Resource // the fields are Unknown if the node does not report them
+	Requested   Resource // Requested is the sum of the requests of Pods
+}
+
+func NewNodeInfo(node *object.Node) *NodeInfo {
+	info := &NodeInfo{
+		Node:        node,
+		Allocatable: Resource{MilliCPU: Unknown, Memory: Unknown},
+	}
+	if node.Status.Allocatable.Cpu != "" {
+		if cpu, err := object.ParseCpu(node.Status.Allocatable.Cpu); err == nil {
+			info.Allocatable.MilliCPU = cpu
+		}
+	}
+	if node.Status.Allocatable.Memory != "" {
+		if memory, err := object.ParseMemory(node.Status.Allocatable.Memory); err == nil {
+			info.Allocatable.Memory = memory
+		}
+	}
+	return info
+}
+
+// AddPod counts pod on the node, a pod whose requests cannot be parsed requests nothing
+func (n *NodeInfo) AddPod(pod *object.Pod) {
+	n.Pods = append(n.Pods, pod)
+	cpu, memory, _ := pod.ResourceRequests()
+	n.Requested.MilliCPU += cpu
+	n.Requested.Memory += memory
+}
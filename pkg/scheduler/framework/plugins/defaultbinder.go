@@ -0,0 +1,28 @@
+package plugins
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/client"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const DefaultBinderName = "DefaultBinder"
+
+// DefaultBinder binds the pod by writing the node name to its config, the pod keeps its
+// resourceVersion so that a pod updated meanwhile is a conflict
+type DefaultBinder struct {
+	client client.RESTClient
+}
+
+func NewDefaultBinder(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &DefaultBinder{client: handle.Client}, nil
+}
+
+func (pl *DefaultBinder) Name() string {
+	return DefaultBinderName
+}
+
+func (pl *DefaultBinder) Bind(state *framework.CycleState, pod *object.Pod, nodeName string) error {
+	pod.Spec.NodeName = nodeName
+	return pl.client.UpdateConfigPod(pod)
+}
@@ -0,0 +1,197 @@
+package plugins
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const InterPodAntiAffinityName = "InterPodAntiAffinity"
+
+const (
+	// preFilterAntiAffinityKey is where InterPodAntiAffinity writes the topology domains it computed
+	preFilterAntiAffinityKey = "PreFilter" + InterPodAntiAffinityName
+
+	errReasonAntiAffinityRulesNotMatch         = "node(s) didn't match pod anti-affinity rules"
+	errReasonExistingAntiAffinityRulesNotMatch = "node(s) didn't satisfy existing pods anti-affinity rules"
+)
+
+// topologyPair is a topology domain, the nodes whose label key is value
+type topologyPair struct {
+	key   string
+	value string
+}
+
+type antiAffinityState struct {
+	// incoming are the domains holding a pod the required anti-affinity of the pod selects
+	incoming map[topologyPair]struct{}
+	// existing are the domains of the pods whose required anti-affinity selects the pod
+	existing map[topologyPair]struct{}
+	// preferred sums the weights of the preferred anti-affinity terms, of the pod or of the pods
+	// already bound, which keep the pod and another one apart in each domain
+	preferred map[topologyPair]int64
+}
+
+// InterPodAntiAffinity keeps the pod out of the topology domains of the pods its required anti-affinity selects,
+// and out of the domains of the pods whose required anti-affinity selects it. The preferred anti-affinity
+// terms, both ways, lower the score of the domains.
+type InterPodAntiAffinity struct {
+	handle *framework.Handle
+}
+
+func NewInterPodAntiAffinity(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &InterPodAntiAffinity{handle: handle}, nil
+}
+
+func (pl *InterPodAntiAffinity) Name() string {
+	return InterPodAntiAffinityName
+}
+
+func (pl *InterPodAntiAffinity) PreFilter(state *framework.CycleState, pod *object.Pod) error {
+	s := &antiAffinityState{
+		incoming:  make(map[topologyPair]struct{}),
+		existing:  make(map[topologyPair]struct{}),
+		preferred: make(map[topologyPair]int64),
+	}
+	state.Write(preFilterAntiAffinityKey, s)
+	if pl.handle.NodeInfos == nil {
+		return nil
+	}
+	podAntiAffinity := getPodAntiAffinity(pod)
+	for _, nodeInfo := range pl.handle.NodeInfos() {
+		for _, existingPod := range nodeInfo.Pods {
+			if podAntiAffinity != nil {
+				for _, term := range podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+					if pair, ok := termPair(&term, pod, existingPod, nodeInfo.Node); ok {
+						s.incoming[pair] = struct{}{}
+					}
+				}
+				for _, term := range podAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+					if pair, ok := termPair(&term.PodAffinityTerm, pod, existingPod, nodeInfo.Node); ok {
+						s.preferred[pair] += int64(term.Weight)
+					}
+				}
+			}
+			existingAntiAffinity := getPodAntiAffinity(existingPod)
+			if existingAntiAffinity == nil {
+				continue
+			}
+			for _, term := range existingAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+				if pair, ok := termPair(&term, existingPod, pod, nodeInfo.Node); ok {
+					s.existing[pair] = struct{}{}
+				}
+			}
+			for _, term := range existingAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				if pair, ok := termPair(&term.PodAffinityTerm, existingPod, pod, nodeInfo.Node); ok {
+					s.preferred[pair] += int64(term.Weight)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (pl *InterPodAntiAffinity) Filter(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) string {
+	s := readAntiAffinityState(state)
+	if s == nil {
+		return ""
+	}
+	for pair := range s.existing {
+		if value, ok := topologyValue(nodeInfo.Node, pair.key); ok && value == pair.value {
+			return errReasonExistingAntiAffinityRulesNotMatch
+		}
+	}
+	for pair := range s.incoming {
+		if value, ok := topologyValue(nodeInfo.Node, pair.key); ok && value == pair.value {
+			return errReasonAntiAffinityRulesNotMatch
+		}
+	}
+	return ""
+}
+
+// Score is minus the weights of the domains of the node, NormalizeScore maps the lowest to 0 and the highest to MaxNodeScore
+func (pl *InterPodAntiAffinity) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	s := readAntiAffinityState(state)
+	if s == nil {
+		return 0
+	}
+	var score int64
+	for pair, weight := range s.preferred {
+		if value, ok := topologyValue(nodeInfo.Node, pair.key); ok && value == pair.value {
+			score -= weight
+		}
+	}
+	return score
+}
+
+func (pl *InterPodAntiAffinity) NormalizeScore(state *framework.CycleState, pod *object.Pod, scores framework.NodeScoreList) {
+	if len(scores) == 0 {
+		return
+	}
+	minScore, maxScore := scores[0].Score, scores[0].Score
+	for _, score := range scores {
+		if score.Score < minScore {
+			minScore = score.Score
+		}
+		if score.Score > maxScore {
+			maxScore = score.Score
+		}
+	}
+	for i := range scores {
+		if maxScore == minScore {
+			scores[i].Score = 0
+		} else {
+			scores[i].Score = framework.MaxNodeScore * (scores[i].Score - minScore) / (maxScore - minScore)
+		}
+	}
+}
+
+// termPair returns the domain of node, where target is bound, if the term of owner selects target
+func termPair(term *object.PodAffinityTerm, owner *object.Pod, target *object.Pod, node *object.Node) (topologyPair, bool) {
+	namespaces := term.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{owner.Namespace}
+	}
+	if !containsString(namespaces, target.Namespace) || !term.LabelSelector.Matches(target.Labels) {
+		return topologyPair{}, false
+	}
+	value, ok := topologyValue(node, term.TopologyKey)
+	if !ok {
+		return topologyPair{}, false
+	}
+	return topologyPair{key: term.TopologyKey, value: value}, true
+}
+
+// topologyValue returns the value of the label key of the node, every node is in its own LabelHostname domain
+func topologyValue(node *object.Node, key string) (string, bool) {
+	if value, ok := node.MetaData.Labels[key]; ok {
+		return value, true
+	}
+	if key == object.LabelHostname {
+		return node.MetaData.Name, true
+	}
+	return "", false
+}
+
+func readAntiAffinityState(state *framework.CycleState) *antiAffinityState {
+	value, ok := state.Read(preFilterAntiAffinityKey)
+	if !ok {
+		return nil
+	}
+	return value.(*antiAffinityState)
+}
+
+func getPodAntiAffinity(pod *object.Pod) *object.PodAntiAffinity {
+	if pod.Spec.Affinity == nil {
+		return nil
+	}
+	return pod.Spec.Affinity.PodAntiAffinity
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
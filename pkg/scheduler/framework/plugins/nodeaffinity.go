@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const NodeAffinityName = "NodeAffinity"
+
+const errReasonNodeAffinity = "node(s) didn't match Pod's node affinity/selector"
+
+// NodeAffinity keeps the nodes matching the nodeSelector and the required node affinity of the pod,
+// and favours the ones matching its preferred node affinity
+type NodeAffinity struct{}
+
+func NewNodeAffinity(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &NodeAffinity{}, nil
+}
+
+func (pl *NodeAffinity) Name() string {
+	return NodeAffinityName
+}
+
+func (pl *NodeAffinity) Filter(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) string {
+	labels := nodeInfo.Node.MetaData.Labels
+	for k, v := range pod.Spec.NodeSelector {
+		if value, ok := labels[k]; !ok || value != v {
+			return errReasonNodeAffinity
+		}
+	}
+	nodeAffinity := getNodeAffinity(pod)
+	if nodeAffinity == nil || nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+	if !nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.Matches(labels) {
+		return errReasonNodeAffinity
+	}
+	return ""
+}
+
+// Score sums the weights of the preferred terms the node matches, NormalizeScore scales them by the highest
+func (pl *NodeAffinity) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	nodeAffinity := getNodeAffinity(pod)
+	if nodeAffinity == nil {
+		return 0
+	}
+	var score int64
+	for i := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		term := &nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[i]
+		if term.Weight > 0 && term.Preference.Matches(nodeInfo.Node.MetaData.Labels) {
+			score += int64(term.Weight)
+		}
+	}
+	return score
+}
+
+func (pl *NodeAffinity) NormalizeScore(state *framework.CycleState, pod *object.Pod, scores framework.NodeScoreList) {
+	var maxScore int64
+	for _, score := range scores {
+		if score.Score > maxScore {
+			maxScore = score.Score
+		}
+	}
+	if maxScore == 0 {
+		return
+	}
+	for i := range scores {
+		scores[i].Score = framework.MaxNodeScore * scores[i].Score / maxScore
+	}
+}
+
+func getNodeAffinity(pod *object.Pod) *object.NodeAffinity {
+	if pod.Spec.Affinity == nil {
+		return nil
+	}
+	return pod.Spec.Affinity.NodeAffinity
+}
@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+	"strings"
+)
+
+const (
+	NodeResourcesFitName = "NodeResourcesFit"
+	LeastAllocatedName   = "LeastAllocated"
+
+	// preFilterStateKey is where NodeResourcesFit writes the requests of the pod
+	preFilterStateKey = "PreFilter" + NodeResourcesFitName
+)
+
+// NodeResourcesFit rejects the nodes without enough allocatable cpu or memory left for the pod,
+// a node which does not report them fits any pod
+type NodeResourcesFit struct{}
+
+func NewNodeResourcesFit(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &NodeResourcesFit{}, nil
+}
+
+func (pl *NodeResourcesFit) Name() string {
+	return NodeResourcesFitName
+}
+
+func (pl *NodeResourcesFit) PreFilter(state *framework.CycleState, pod *object.Pod) error {
+	cpu, memory, err := pod.ResourceRequests()
+	if err != nil {
+		return err
+	}
+	state.Write(preFilterStateKey, framework.Resource{MilliCPU: cpu, Memory: memory})
+	return nil
+}
+
+func (pl *NodeResourcesFit) Filter(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) string {
+	request := podRequest(state, pod)
+	var reasons []string
+	if nodeInfo.Allocatable.MilliCPU != framework.Unknown && nodeInfo.Requested.MilliCPU+request.MilliCPU > nodeInfo.Allocatable.MilliCPU {
+		reasons = append(reasons, "Insufficient cpu")
+	}
+	if nodeInfo.Allocatable.Memory != framework.Unknown && nodeInfo.Requested.Memory+request.Memory > nodeInfo.Allocatable.Memory {
+		reasons = append(reasons, "Insufficient memory")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// LeastAllocated prefers the nodes with the largest share of their resources left once the pod is bound
+type LeastAllocated struct{}
+
+func NewLeastAllocated(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &LeastAllocated{}, nil
+}
+
+func (pl *LeastAllocated) Name() string {
+	return LeastAllocatedName
+}
+
+func (pl *LeastAllocated) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	request := podRequest(state, pod)
+	return (freeShare(nodeInfo.Requested.MilliCPU+request.MilliCPU, nodeInfo.Allocatable.MilliCPU) +
+		freeShare(nodeInfo.Requested.Memory+request.Memory, nodeInfo.Allocatable.Memory)) / 2
+}
+
+func freeShare(requested int64, allocatable int64) int64 {
+	if allocatable == framework.Unknown || allocatable == 0 || requested >= allocatable {
+		return 0
+	}
+	return (allocatable - requested) * framework.MaxNodeScore / allocatable
+}
+
+// podRequest returns the requests computed by PreFilter, or computes them if NodeResourcesFit is not enabled
+func podRequest(state *framework.CycleState, pod *object.Pod) framework.Resource {
+	if value, ok := state.Read(preFilterStateKey); ok {
+		return value.(framework.Resource)
+	}
+	cpu, memory, _ := pod.ResourceRequests()
+	return framework.Resource{MilliCPU: cpu, Memory: memory}
+}
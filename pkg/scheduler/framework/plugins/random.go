@@ -0,0 +1,24 @@
+package plugins
+
+import (
+	"math/rand"
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const RandomName = "Random"
+
+// Random scores the nodes randomly, alone it spreads the pods on the nodes at random
+type Random struct{}
+
+func NewRandom(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &Random{}, nil
+}
+
+func (pl *Random) Name() string {
+	return RandomName
+}
+
+func (pl *Random) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	return rand.Int63n(framework.MaxNodeScore + 1)
+}
@@ -0,0 +1,18 @@
+package plugins
+
+import "minik8s/pkg/scheduler/framework"
+
+// NewInTreeRegistry returns the plugins built in the scheduler, register the others on it
+func NewInTreeRegistry() framework.Registry {
+	return framework.Registry{
+		NodeResourcesFitName:     NewNodeResourcesFit,
+		LeastAllocatedName:       NewLeastAllocated,
+		RandomName:               NewRandom,
+		RoundRobinName:           NewRoundRobin,
+		NodeAffinityName:         NewNodeAffinity,
+		InterPodAntiAffinityName: NewInterPodAntiAffinity,
+		SelectorSpreadName:       NewSelectorSpread,
+		TaintTolerationName:      NewTaintToleration,
+		DefaultBinderName:        NewDefaultBinder,
+	}
+}
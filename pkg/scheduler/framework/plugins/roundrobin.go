@@ -0,0 +1,49 @@
+package plugins
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+	"sort"
+	"sync"
+)
+
+const RoundRobinName = "RoundRobin"
+
+// RoundRobin gives the full score to the nodes in turn, in the order of their names
+type RoundRobin struct {
+	count int
+	lock  sync.Mutex
+}
+
+func NewRoundRobin(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &RoundRobin{}, nil
+}
+
+func (pl *RoundRobin) Name() string {
+	return RoundRobinName
+}
+
+func (pl *RoundRobin) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	return 0
+}
+
+// NormalizeScore picks the next node, the scores of the other nodes stay 0
+func (pl *RoundRobin) NormalizeScore(state *framework.CycleState, pod *object.Pod, scores framework.NodeScoreList) {
+	if len(scores) == 0 {
+		return
+	}
+	names := make([]string, 0, len(scores))
+	for _, score := range scores {
+		names = append(names, score.Name)
+	}
+	sort.Strings(names)
+	pl.lock.Lock()
+	next := names[pl.count%len(names)]
+	pl.count++
+	pl.lock.Unlock()
+	for i := range scores {
+		if scores[i].Name == next {
+			scores[i].Score = framework.MaxNodeScore
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package plugins
+
+import (
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const SelectorSpreadName = "SelectorSpread"
+
+// SelectorSpread spreads the pods with the same labels, usually the replicas of a replicaset, on the nodes.
+// The nodes running the fewest of them score the most.
+type SelectorSpread struct{}
+
+func NewSelectorSpread(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &SelectorSpread{}, nil
+}
+
+func (pl *SelectorSpread) Name() string {
+	return SelectorSpreadName
+}
+
+// Score counts the pods of the node matching the labels of the pod, NormalizeScore reverses it
+func (pl *SelectorSpread) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	if len(pod.Labels) == 0 {
+		return 0
+	}
+	var count int64
+	for _, other := range nodeInfo.Pods {
+		if other.Namespace == pod.Namespace && hasLabels(other.Labels, pod.Labels) {
+			count++
+		}
+	}
+	return count
+}
+
+func (pl *SelectorSpread) NormalizeScore(state *framework.CycleState, pod *object.Pod, scores framework.NodeScoreList) {
+	var maxCount int64
+	for _, score := range scores {
+		if score.Score > maxCount {
+			maxCount = score.Score
+		}
+	}
+	for i := range scores {
+		if maxCount == 0 {
+			scores[i].Score = framework.MaxNodeScore
+		} else {
+			scores[i].Score = framework.MaxNodeScore * (maxCount - scores[i].Score) / maxCount
+		}
+	}
+}
+
+// hasLabels is true if labels contains every label of selector
+func hasLabels(labels map[string]string, selector map[string]string) bool {
+	for k, v := range selector {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package plugins
+
+import (
+	"fmt"
+	"minik8s/object"
+	"minik8s/pkg/scheduler/framework"
+)
+
+const TaintTolerationName = "TaintToleration"
+
+// TaintToleration keeps the pods off the nodes with NoSchedule or NoExecute taints they do not tolerate,
+// and favours the nodes with the fewest PreferNoSchedule taints they do not tolerate
+type TaintToleration struct{}
+
+func NewTaintToleration(args map[string]string, handle *framework.Handle) (framework.Plugin, error) {
+	return &TaintToleration{}, nil
+}
+
+func (pl *TaintToleration) Name() string {
+	return TaintTolerationName
+}
+
+func (pl *TaintToleration) Filter(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) string {
+	for i := range nodeInfo.Node.Spec.Taints {
+		taint := &nodeInfo.Node.Spec.Taints[i]
+		if taint.Effect != object.TaintEffectNoSchedule && taint.Effect != object.TaintEffectNoExecute {
+			continue
+		}
+		if _, ok := object.FindToleration(pod.Spec.Tolerations, taint); !ok {
+			return fmt.Sprintf("node(s) had untolerated taint {%s: %s}", taint.Key, taint.Value)
+		}
+	}
+	return ""
+}
+
+// Score counts the untolerated PreferNoSchedule taints, NormalizeScore turns the counts upside down
+func (pl *TaintToleration) Score(state *framework.CycleState, pod *object.Pod, nodeInfo *framework.NodeInfo) int64 {
+	var count int64
+	for i := range nodeInfo.Node.Spec.Taints {
+		taint := &nodeInfo.Node.Spec.Taints[i]
+		if taint.Effect != object.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if _, ok := object.FindToleration(pod.Spec.Tolerations, taint); !ok {
+			count++
+		}
+	}
+	return count
+}
+
+func (pl *TaintToleration) NormalizeScore(state *framework.CycleState, pod *object.Pod, scores framework.NodeScoreList) {
+	var maxCount int64
+	for _, score := range scores {
+		if score.Score > maxCount {
+			maxCount = score.Score
+		}
+	}
+	for i := range scores {
+		if maxCount == 0 {
+			scores[i].Score = framework.MaxNodeScore
+		} else {
+			scores[i].Score = framework.MaxNodeScore - framework.MaxNodeScore*scores[i].Score/maxCount
+		}
+	}
+}
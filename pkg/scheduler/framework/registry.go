@@ -0,0 +1,25 @@
+package framework
+
+import "fmt"
+
+// Registry maps the names of the plugins to their factories
+type Registry map[string]PluginFactory
+
+// Register adds a plugin which is not built in, the name must not be taken
+func (r Registry) Register(name string, factory PluginFactory) error {
+	if _, ok := r[name]; ok {
+		return fmt.Errorf("a plugin named %s already exists", name)
+	}
+	r[name] = factory
+	return nil
+}
+
+// Merge registers every plugin of other
+func (r Registry) Merge(other Registry) error {
+	for name, factory := range other {
+		if err := r.Register(name, factory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
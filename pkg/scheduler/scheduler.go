@@ -2,37 +2,76 @@ package scheduler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
 	"minik8s/object"
-	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/listerwatcher"
+	"minik8s/pkg/scheduler/framework"
+	"minik8s/pkg/scheduler/framework/plugins"
 	"minik8s/util/queue"
-	"sync"
-	"time"
+	"reflect"
 )
 
-var globalCount int
-var lock sync.Mutex
+// maxRetries is the number of times a pod is retried before it is dropped,
+// it is scheduled again on its next update
+const maxRetries = 15
 
+// the selection policies, DefaultConfig returns their plugins
 const (
 	SelectRandom     string = "1"
 	SelectRoundRobin string = "2"
 	SelectAffinity   string = "3"
+	// SelectLeastAllocated prefers the nodes with the most resources left
+	SelectLeastAllocated string = "4"
 )
 
+// DefaultConfig returns the plugins of a selection policy. Every policy only selects among the nodes
+// with room for the pod which its nodeSelector, affinity and anti-affinity allow.
+func DefaultConfig(selectType string) *framework.Config {
+	cfg := &framework.Config{
+		Plugins: []framework.PluginConfig{
+			{Name: plugins.NodeResourcesFitName},
+			{Name: plugins.TaintTolerationName},
+			{Name: plugins.NodeAffinityName},
+			{Name: plugins.InterPodAntiAffinityName},
+		},
+	}
+	switch selectType {
+	case SelectRandom:
+		cfg.Plugins = append(cfg.Plugins, framework.PluginConfig{Name: plugins.RandomName})
+	case SelectRoundRobin:
+		cfg.Plugins = append(cfg.Plugins, framework.PluginConfig{Name: plugins.RoundRobinName})
+	case SelectAffinity:
+		// only the preferred affinities score, the nodes they do not tell apart are selected at random
+	default:
+		cfg.Plugins = append(cfg.Plugins, framework.PluginConfig{Name: plugins.LeastAllocatedName})
+	}
+	cfg.Plugins = append(cfg.Plugins, framework.PluginConfig{Name: plugins.DefaultBinderName})
+	return cfg
+}
+
 type Scheduler struct {
-	ls          *listerwatcher.ListerWatcher
-	stopChannel <-chan struct{}
-	queue       queue.ConcurrentQueue
-	selectType  string
-	Client      client.RESTClient
+	ls              *listerwatcher.ListerWatcher
+	informerFactory *informer.SharedInformerFactory
+	podInformer     *informer.Informer[object.Pod]
+	nodeInformer    *informer.Informer[object.Node]
+	stopChannel     chan struct{}
+	// assumed are the pods bound by the scheduler which the informer has not told of yet,
+	// they are counted on their nodes meanwhile. Only the worker uses it.
+	assumed map[string]*object.Pod
+	// snapshot are the nodes of the pod being scheduled, the plugins get them from the Handle
+	snapshot []*framework.NodeInfo
+	// working queue of the keys of pod configs
+	queue     *queue.RateLimitingQueue[string]
+	framework *framework.Framework
+	Client    client.RESTClient
 }
 
-func NewScheduler(lsConfig *listerwatcher.Config, clientConfig client.Config, selectType string) *Scheduler {
+// NewScheduler returns a scheduler running the plugins of cfg, outOfTreeRegistry holds the plugins
+// which are not built in and can be nil.
+func NewScheduler(lsConfig *listerwatcher.Config, clientConfig client.Config, cfg *framework.Config, outOfTreeRegistry framework.Registry) (*Scheduler, error) {
 	ls, err := listerwatcher.NewListerWatcher(lsConfig)
 	if err != nil {
 		fmt.Println(err)
@@ -43,155 +82,206 @@ func NewScheduler(lsConfig *listerwatcher.Config, clientConfig client.Config, se
 		Base: "http://" + clientConfig.Host,
 	}
 
+	registry := plugins.NewInTreeRegistry()
+	if err := registry.Merge(outOfTreeRegistry); err != nil {
+		return nil, err
+	}
+	informerFactory := informer.NewSharedInformerFactory(ls, 0)
 	rsc := &Scheduler{
-		ls:     ls,
-		Client: restClient,
+		ls:              ls,
+		informerFactory: informerFactory,
+		podInformer:     informerFactory.ConfigPods(),
+		nodeInformer:    informerFactory.Nodes(),
+		assumed:         make(map[string]*object.Pod),
+		queue:           queue.NewRateLimitingQueue[string]("scheduler", maxRetries),
+		Client:          restClient,
 	}
 	rsc.stopChannel = make(chan struct{})
-	rsc.selectType = selectType
-	return rsc
+	handle := &framework.Handle{
+		Client: restClient,
+		NodeInfos: func() []*framework.NodeInfo {
+			return rsc.snapshot
+		},
+	}
+	rsc.framework, err = framework.NewFramework(registry, cfg, handle)
+	if err != nil {
+		return nil, err
+	}
+	return rsc, nil
 }
 
 // Run begins watching and syncing.
 func (sched *Scheduler) Run(ctx context.Context) {
 	fmt.Printf("[Scheduler]start running\n")
-	go sched.register()
+	sched.register()
+	sched.informerFactory.Start(sched.stopChannel)
 	go sched.worker(ctx)
-	select {}
+	<-ctx.Done()
+	close(sched.stopChannel)
+	sched.queue.ShutDown()
 }
 
 func (sched *Scheduler) register() {
-	err := sched.ls.Watch(config.PodConfigPREFIX, sched.watchNewPod, sched.stopChannel)
-	if err != nil {
-		fmt.Println(err)
-		fmt.Printf("[Scheduler] ListWatch init fail...\n")
+	sched.podInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Pod]{
+		AddFunc: sched.watchNewPod,
+		UpdateFunc: func(key string, oldPod *object.Pod, newPod *object.Pod) {
+			// the status of an unschedulable pod is written by the scheduler itself
+			if reflect.DeepEqual(oldPod.Spec, newPod.Spec) && oldPod.Status.Phase == newPod.Status.Phase {
+				return
+			}
+			sched.watchNewPod(key, newPod)
+		},
+		DeleteFunc: func(key string, pod *object.Pod) {
+			// a pod deleted frees room on its node
+			sched.requeuePendingPods()
+		},
+	})
+	sched.nodeInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Node]{
+		AddFunc: func(key string, node *object.Node) {
+			sched.requeuePendingPods()
+		},
+		UpdateFunc: func(key string, oldNode *object.Node, newNode *object.Node) {
+			if !reflect.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) {
+				sched.requeuePendingPods()
+			}
+		},
+	})
+}
+
+// requeuePendingPods queues the pods waiting for a node again, some may fit now
+func (sched *Scheduler) requeuePendingPods() {
+	for _, key := range sched.podInformer.Keys() {
+		if pod, ok := sched.podInformer.Get(key); ok && needsScheduling(pod) {
+			sched.queue.Add(key)
+		}
 	}
 }
 
+func needsScheduling(pod *object.Pod) bool {
+	return pod.Spec.NodeName == "" && pod.Status.Phase != object.Delete
+}
+
 func (sched *Scheduler) worker(ctx context.Context) {
 	fmt.Printf("[worker] Starting...\n")
-	for {
-		if !sched.queue.Empty() {
-			podPtr := sched.queue.Front()
-			sched.queue.Dequeue()
-			sched.schedulePod(ctx, podPtr.(*object.Pod))
-		} else {
-			time.Sleep(time.Second)
-		}
+	for sched.processNextWorkItem(ctx) {
+	}
+}
+
+func (sched *Scheduler) processNextWorkItem(ctx context.Context) bool {
+	key, shutdown := sched.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer sched.queue.Done(key)
+
+	cached, ok := sched.podInformer.Get(key)
+	if !ok || !needsScheduling(cached) {
+		// deleted or scheduled since it was queued
+		sched.queue.Forget(key)
+		return true
 	}
+	// the cached pod is shared, it keeps its version so that a pod updated meanwhile is a conflict
+	pod := *cached
+	err := sched.schedulePod(ctx, key, &pod)
+	if err == nil {
+		sched.queue.Forget(key)
+		return true
+	}
+	if sched.queue.AddRateLimited(key) {
+		fmt.Printf("[worker] schedule pod %s fail, retrying: %s\n", key, err.Error())
+	} else {
+		fmt.Printf("[worker] schedule pod %s fail, dropped: %s\n", key, err.Error())
+	}
+	return true
 }
 
-func (sched *Scheduler) schedulePod(ctx context.Context, pod *object.Pod) error {
+func (sched *Scheduler) schedulePod(ctx context.Context, key string, pod *object.Pod) error {
 	fmt.Printf("[schedulePod] Begin scheduling\n")
-	nodes, err := sched.getNodes()
+	sched.snapshot = sched.nodeInfos()
+	state := framework.NewCycleState()
+	err := sched.framework.RunPreFilterPlugins(state, pod)
 	if err != nil {
-		fmt.Printf(err.Error())
-		return err
+		return sched.markUnschedulable(pod, err.Error())
+	}
+	feasible, message := sched.framework.RunFilterPlugins(state, pod, sched.snapshot)
+	if len(feasible) == 0 {
+		return sched.markUnschedulable(pod, message)
 	}
 	// select a host for the pod
-	var nodeName string
-	switch sched.selectType {
-	case SelectRandom:
-		nodeName = selectHostRandom(nodes)
-		break
-	case SelectRoundRobin:
-		nodeName = selectHostRoundRobin(nodes)
-		break
-	case SelectAffinity:
-		nodeName = selectHostWithAffinity(nodes, pod.Labels)
-		break
+	nodeName := feasible[0].Node.MetaData.Name
+	if len(feasible) > 1 {
+		scores, err := sched.framework.RunScorePlugins(state, pod, feasible)
+		if err != nil {
+			return err
+		}
+		nodeName = framework.SelectHost(scores)
 	}
-	fmt.Printf("the nodeName choice is:%s\n", nodeName)
 	fmt.Printf("[schedulePod]assign pod to node:%s\n", nodeName)
-	// modify pod host
-	pod.Spec.NodeName = nodeName
-	// update pod to api server
-	err = sched.Client.UpdateConfigPod(pod)
-	return err
-}
-func (sched *Scheduler) getNodes() ([]object.Node, error) {
-	raw, err := sched.ls.List(config.NODE_PREFIX)
-	if err != nil {
-		return nil, err
-	}
-	var res []object.Node
-	if len(raw) == 0 {
-		return res, nil
+	if pod.Status.Reason == object.PodReasonUnschedulable {
+		pod.Status.Reason = ""
+		pod.Status.Err = ""
 	}
-	for _, rawPair := range raw {
-		node := &object.Node{}
-		err = json.Unmarshal(rawPair.ValueBytes, node)
-		res = append(res, *node)
+	err = sched.framework.RunBindPlugins(state, pod, nodeName)
+	if err != nil {
+		return err
 	}
-	return res, nil
+	sched.assumed[key] = pod
+	return nil
 }
 
-// select a node as host
-// select police
-func selectHostRandom(nodes []object.Node) string {
-	if len(nodes) == 0 {
-		return ""
-	}
-	num := len(nodes)
-	fmt.Printf("there are %d nodes in totle", num)
-	rand.Seed(time.Now().Unix())
-	idx := rand.Intn(num)
-	return nodes[idx].MetaData.Name
-}
-func selectHostRoundRobin(nodes []object.Node) string {
-	lock.Lock()
-	defer lock.Unlock()
-	num := len(nodes)
-	if num == 0 {
-		return ""
-	}
-	idx := globalCount % num
-	globalCount++
-	return nodes[idx].MetaData.Name
-}
-func match(map1 map[string]string, map2 map[string]string) bool {
-	for k, v := range map1 {
-		v2, ok := map2[k]
-		if !ok {
-			continue
-		} else {
-			if v == v2 {
-				return true
-			}
-			continue
+// markUnschedulable leaves the pod pending with the reason no node fits it, and returns that as an error to retry later
+func (sched *Scheduler) markUnschedulable(pod *object.Pod, message string) error {
+	fmt.Printf("[schedulePod] pod %s is unschedulable: %s\n", pod.Name, message)
+	if pod.Status.Phase != object.PodPending || pod.Status.Reason != object.PodReasonUnschedulable || pod.Status.Err != message {
+		pod.Status.Phase = object.PodPending
+		pod.Status.Reason = object.PodReasonUnschedulable
+		pod.Status.Err = message
+		if err := sched.Client.UpdateConfigPod(pod); err != nil {
+			return err
 		}
 	}
-	return false
+	return errors.New(message)
 }
 
-//使用labels进行挑选
-func selectHostWithAffinity(nodes []object.Node, labels map[string]string) string {
-	if labels == nil {
-		return selectHostRandom(nodes)
+// nodeInfos returns every node with the pods bound to it
+func (sched *Scheduler) nodeInfos() []*framework.NodeInfo {
+	infos := make(map[string]*framework.NodeInfo)
+	var ret []*framework.NodeInfo
+	for _, node := range sched.nodeInformer.List() {
+		info := framework.NewNodeInfo(node)
+		infos[node.MetaData.Name] = info
+		ret = append(ret, info)
 	}
-	for _, node := range nodes {
-		if node.MetaData.Labels == nil {
-			continue
+	count := func(pod *object.Pod) {
+		info, ok := infos[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase == object.Delete || pod.Status.Phase == object.PodSucceeded || pod.Status.Phase == object.Failed {
+			return
 		}
-		if match(labels, node.MetaData.Labels) {
-			return node.MetaData.Name
-		} else {
+		info.AddPod(pod)
+	}
+	for _, key := range sched.podInformer.Keys() {
+		pod, ok := sched.podInformer.Get(key)
+		if !ok {
 			continue
 		}
+		if pod.Spec.NodeName != "" {
+			delete(sched.assumed, key)
+			count(pod)
+		} else if assumed, ok := sched.assumed[key]; ok {
+			count(assumed)
+		}
+	}
+	for key := range sched.assumed {
+		if _, ok := sched.podInformer.Get(key); !ok {
+			delete(sched.assumed, key)
+		}
 	}
-	return selectHostRandom(nodes)
+	return ret
 }
 
 // watch the change of new pods
-func (sched *Scheduler) watchNewPod(res etcdstore.WatchRes) {
-	pod := &object.Pod{}
-	err := json.Unmarshal(res.ValueBytes, pod)
-	if err != nil {
-		fmt.Printf("watchNewPod bad message pod:%+v\n", pod)
-		return
-	}
-
-	if pod.Spec.NodeName != "" {
+func (sched *Scheduler) watchNewPod(key string, pod *object.Pod) {
+	if !needsScheduling(pod) {
 		return
 	}
 
@@ -199,5 +289,5 @@ func (sched *Scheduler) watchNewPod(res etcdstore.WatchRes) {
 	fmt.Printf("watch new Config Pod with name:%s\n", pod.Name)
 
 	fmt.Printf("[watchNewPod] new message from watcher...\n")
-	sched.queue.Enqueue(pod)
+	sched.queue.Add(key)
 }
@@ -2,13 +2,12 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"minik8s/cmd/kube-controller-manager/util"
 	"minik8s/object"
 	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/etcdstore"
+	"minik8s/pkg/informer"
 	"minik8s/pkg/listerwatcher"
 	"minik8s/pkg/netSupport/netconfig"
 	"sync"
@@ -16,14 +15,17 @@ import (
 )
 
 type Manager struct {
-	//从service name 到 RuntimeService的映射
-	serviceMap   map[string]*RuntimeService
-	ls           *listerwatcher.ListerWatcher
-	clientConfig client.Config
-	stopChannel  chan struct{}
-	client       client.RESTClient
-	name2DnsMap  map[string]*object.DnsAndTrans
-	lock         sync.Mutex
+	//从service namespace/name 到 RuntimeService的映射
+	serviceMap      map[string]*RuntimeService
+	serviceInformer *informer.Informer[object.Service]
+	dnsInformer     *informer.Informer[object.DnsAndTrans]
+	nodeInformer    *informer.Informer[object.Node]
+	podInformer     *informer.Informer[object.Pod]
+	clientConfig    client.Config
+	stopChannel     chan struct{}
+	client          client.RESTClient
+	name2DnsMap     map[string]*object.DnsAndTrans
+	lock            sync.Mutex
 }
 
 // Deprecated: Use NewServiceController and Manager.Run instead.
@@ -41,9 +43,11 @@ func NewManager(lsConfig *listerwatcher.Config, clientConfig client.Config) *Man
 	if err != nil {
 		fmt.Println("[Service Manager] newManager fail")
 	}
-	manager.ls = ls
+	informerFactory := informer.NewSharedInformerFactory(ls, 0)
+	manager.setInformers(informerFactory)
+	informerFactory.Start(manager.stopChannel)
 	manager.clientConfig = clientConfig
-	manager.register()
+	go manager.register()
 	go manager.checkAndBoot()
 	go manager.checkDnsAndTrans()
 	return manager
@@ -59,13 +63,20 @@ func NewServiceController(controllerCtx util.ControllerContext) *Manager {
 	manager.client = client.RESTClient{
 		Base: "http://" + controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort,
 	}
-	manager.ls = controllerCtx.Ls
+	manager.setInformers(controllerCtx.InformerFactory)
 	manager.clientConfig = client.Config{Host: controllerCtx.MasterIP + ":" + controllerCtx.HttpServerPort}
 	return manager
 }
 
+func (manager *Manager) setInformers(informerFactory *informer.SharedInformerFactory) {
+	manager.serviceInformer = informerFactory.Services()
+	manager.dnsInformer = informerFactory.DnsAndTrans()
+	manager.nodeInformer = informerFactory.Nodes()
+	manager.podInformer = informerFactory.Pods()
+}
+
 func (manager *Manager) Run(ctx context.Context) {
-	manager.register()
+	go manager.register()
 	go manager.checkAndBoot()
 	go manager.checkDnsAndTrans()
 	<-ctx.Done()
@@ -79,7 +90,7 @@ func (manager *Manager) checkDnsAndTrans() {
 		manager.lock.Lock()
 		var removes []string
 		for k, v := range manager.name2DnsMap {
-			resp, err := manager.client.GetRuntimeService(netconfig.GateWayServicePrefix + k)
+			resp, err := manager.client.GetRuntimeService(v.MetaData.Namespace, netconfig.GateWayServicePrefix+v.MetaData.Name)
 			if err != nil {
 				fmt.Println("[checkDnsAndTrans] getRuntimeService fail" + err.Error())
 				continue
@@ -109,12 +120,7 @@ func (manager *Manager) checkDnsAndTrans() {
 func (manager *Manager) checkAndBoot() {
 	for {
 		time.Sleep(5 * time.Second)
-		res, err := manager.ls.List(config.NODE_PREFIX)
-		if err != nil {
-			fmt.Println("[ServiceManager] checkAndBoot error" + err.Error())
-			continue
-		}
-		if len(res) == 0 {
+		if len(manager.nodeInformer.List()) == 0 {
 			continue
 		} else {
 			manager.boot()
@@ -124,7 +130,7 @@ func (manager *Manager) checkAndBoot() {
 }
 func (manager *Manager) boot() {
 	//查看一下是否已经存在coreDns service, 存在的话不再生成
-	res, err := manager.client.GetRuntimeService("dnsService")
+	res, err := manager.client.GetRuntimeService(config.DefaultNamespace, "dnsService")
 	if res != nil {
 		return
 	}
@@ -142,65 +148,56 @@ func (manager *Manager) boot() {
 	}
 }
 func (manager *Manager) register() {
-	watchService := func() {
-		for {
-			err := manager.ls.Watch(config.ServiceConfigPrefix, manager.watchServiceConfig, manager.stopChannel)
-			if err != nil {
-				fmt.Println("[Service Manager] register error" + err.Error())
-				time.Sleep(5 * time.Second)
-			} else {
-				return
-			}
-		}
-
-	}
-	watchDns := func() {
-		for {
-			err := manager.ls.Watch(config.DnsAndTransPrefix, manager.watchDnsAndTrans, manager.stopChannel)
-			if err != nil {
-				fmt.Println("[Server Manager] register error" + err.Error())
-				time.Sleep(5 * time.Second)
-			} else {
-				return
-			}
-		}
-	}
-	go watchService()
-	go watchDns()
-}
-func (manager *Manager) watchDnsAndTrans(res etcdstore.WatchRes) {
-	if res.ResType == etcdstore.DELETE {
+	// the services select their pods from the cache
+	if !informer.WaitForCacheSync(manager.stopChannel, manager.podInformer.HasSynced, manager.serviceInformer.HasSynced, manager.dnsInformer.HasSynced) {
 		return
 	}
+	manager.serviceInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.Service]{
+		AddFunc: manager.watchServiceConfig,
+		UpdateFunc: func(key string, oldService *object.Service, newService *object.Service) {
+			manager.watchServiceConfig(key, newService)
+		},
+	})
+	manager.dnsInformer.AddEventHandler(informer.ResourceEventHandlerFuncs[object.DnsAndTrans]{
+		AddFunc: manager.watchDnsAndTrans,
+		UpdateFunc: func(key string, oldTrans *object.DnsAndTrans, newTrans *object.DnsAndTrans) {
+			manager.watchDnsAndTrans(key, newTrans)
+		},
+	})
+}
+func (manager *Manager) watchDnsAndTrans(key string, cached *object.DnsAndTrans) {
+	// the cached object is shared, the copy is kept in name2DnsMap and modified later
 	DnsAndTrans := &object.DnsAndTrans{}
+	*DnsAndTrans = *cached
+	DnsAndTrans.MetaData.ResourceVersion = ""
 	fmt.Println("[ServiceManager]watch Dns")
-	err := json.Unmarshal(res.ValueBytes, DnsAndTrans)
-	if err != nil {
-		fmt.Println("[ServiceManager] Unmarshall error")
-		return
-	}
+	var err error
 	if DnsAndTrans.Status.Phase == object.Delete {
-		err = manager.client.DeleteService(netconfig.GateWayServicePrefix + DnsAndTrans.MetaData.Name)
+		err = manager.client.DeleteService(DnsAndTrans.MetaData.Namespace, netconfig.GateWayServicePrefix+DnsAndTrans.MetaData.Name)
 		if err != nil {
 			fmt.Println("[ServiceManager] watchDns: deleteService fail")
 			fmt.Println(err)
 			return
 		}
-		err = manager.client.DeleteConfigRs(netconfig.GateWayServicePrefix + DnsAndTrans.MetaData.Name)
+		err = manager.client.DeleteConfigRs(DnsAndTrans.MetaData.Namespace, netconfig.GateWayServicePrefix+DnsAndTrans.MetaData.Name)
 		if err != nil {
 			fmt.Println("[ServiceManager] watchDns: deleteRs fail")
 			fmt.Println(err)
 			return
 		}
 	} else if DnsAndTrans.Status.Phase == object.FileCreated {
-		//需要生成rs以及service
-		err = manager.client.AddConfigRs(GetGateWayRsModule(DnsAndTrans.MetaData.Name))
+		//需要生成rs以及service, 和DnsAndTrans处于同一个namespace
+		gateWayRs := GetGateWayRsModule(DnsAndTrans.MetaData.Name)
+		gateWayRs.Namespace = DnsAndTrans.MetaData.Namespace
+		err = manager.client.AddConfigRs(gateWayRs)
 		if err != nil {
 			fmt.Println("[ServiceManager] watchDns: addRs fail")
 			fmt.Println(err)
 			return
 		}
-		err = manager.client.UpdateService(GetGateWayServiceModule(DnsAndTrans.MetaData.Name))
+		gateWayService := GetGateWayServiceModule(DnsAndTrans.MetaData.Name)
+		gateWayService.MetaData.Namespace = DnsAndTrans.MetaData.Namespace
+		err = manager.client.UpdateService(gateWayService)
 		if err != nil {
 			fmt.Println("[ServiceManager] watchDns: updateService fail")
 			fmt.Println(err)
@@ -208,7 +205,7 @@ func (manager *Manager) watchDnsAndTrans(res etcdstore.WatchRes) {
 		}
 		//加入等待service部署的map
 		manager.lock.Lock()
-		manager.name2DnsMap[DnsAndTrans.MetaData.Name] = DnsAndTrans
+		manager.name2DnsMap[config.NamespacedName(DnsAndTrans.MetaData.Namespace, DnsAndTrans.MetaData.Name)] = DnsAndTrans
 		manager.lock.Unlock()
 		//wait := 0
 		//for {
@@ -246,36 +243,33 @@ func (manager *Manager) watchDnsAndTrans(res etcdstore.WatchRes) {
 	}
 
 }
-func (manager *Manager) watchServiceConfig(res etcdstore.WatchRes) {
-	if res.ResType == etcdstore.DELETE {
-		//不会有真删除的情况, 配置文件的删除通过设置status为DELETE
-		return
-	}
+// 不会有真删除的情况, 配置文件的删除通过设置status为DELETE
+func (manager *Manager) watchServiceConfig(etcdKey string, cached *object.Service) {
+	// the cached object is shared, the runtime service keeps and modifies a copy which is written to another key
 	service := &object.Service{}
+	*service = *cached
+	service.MetaData.ResourceVersion = ""
 	fmt.Println("[service manager]Watch receive")
-	err := json.Unmarshal(res.ValueBytes, service)
-	if err != nil {
-		fmt.Println("[ServiceManager] Unmarshall error")
-	}
+	key := config.NamespacedName(service.MetaData.Namespace, service.MetaData.Name)
 	if service.Status.Phase == object.Delete {
 		//需要删除service
-		runtimeService, ok := manager.serviceMap[service.MetaData.Name]
+		runtimeService, ok := manager.serviceMap[key]
 		if !ok {
 			return
 		}
 		runtimeService.DeleteService()
-		delete(manager.serviceMap, service.MetaData.Name)
+		delete(manager.serviceMap, key)
 	} else {
 		//可能是更新或者启动service
-		runtimeService, ok := manager.serviceMap[service.MetaData.Name]
+		runtimeService, ok := manager.serviceMap[key]
 		if !ok {
 			//新建service
-			manager.serviceMap[service.MetaData.Name] = NewRuntimeService(service, manager.ls, manager.clientConfig)
+			manager.serviceMap[key] = NewRuntimeService(service, manager.podInformer, manager.clientConfig)
 		} else {
 			//修改service, 直接删了重新建一个
 			runtimeService.DeleteService()
-			delete(manager.serviceMap, service.MetaData.Name)
-			manager.serviceMap[service.MetaData.Name] = NewRuntimeService(service, manager.ls, manager.clientConfig)
+			delete(manager.serviceMap, key)
+			manager.serviceMap[key] = NewRuntimeService(service, manager.podInformer, manager.clientConfig)
 		}
 	}
 }
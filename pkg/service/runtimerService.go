@@ -1,12 +1,10 @@
 package service
 
 import (
-	"encoding/json"
 	"fmt"
 	"minik8s/object"
-	"minik8s/pkg/apiserver/config"
 	"minik8s/pkg/client"
-	"minik8s/pkg/listerwatcher"
+	"minik8s/pkg/informer"
 	"sync"
 	"time"
 )
@@ -22,7 +20,7 @@ type RuntimeService struct {
 	serviceConfig *object.Service
 	//service选择的Pod
 	pods          []*object.Pod
-	ls            *listerwatcher.ListerWatcher
+	podInformer   *informer.Informer[object.Pod]
 	timerStopChan chan bool
 	commandChan   chan command
 	Client        client.RESTClient
@@ -68,20 +66,13 @@ func compareOldAndNew(oldPods []*object.Pod, newPods []*object.Pod) bool {
 }
 func (service *RuntimeService) selectPods(isInit bool) error {
 	selector := service.serviceConfig.Spec.Selector
-	res, err := service.ls.List(config.PodRuntimePrefix)
-	if err != nil {
-		return err
-	}
-	var origin []*object.Pod
-	for _, val := range res {
-		tmp := &object.Pod{}
-		err = json.Unmarshal(val.ValueBytes, tmp)
-		origin = append(origin, tmp)
-	}
+	// a service only selects pods in its own namespace
+	origin := service.podInformer.ByIndex(informer.NamespaceIndex, service.serviceConfig.MetaData.Namespace)
 	//select pods
 	var filter []*object.Pod
 	for _, val := range origin {
-		if val.Status.Phase != object.Running {
+		// an unready pod, e.g. failing its readiness probe, gets no traffic
+		if !val.IsReady() {
 			continue
 		}
 		//考虑label，端口没开放是用户自己的问题，这里不管
@@ -108,7 +99,7 @@ func (service *RuntimeService) selectPods(isInit bool) error {
 	//先把service里的坏的给去掉
 	var okPods []*object.Pod
 	for _, val := range service.pods {
-		if val.Status.Phase == object.Running {
+		if val.IsReady() {
 			okPods = append(okPods, val)
 		}
 	}
@@ -131,7 +122,9 @@ func (service *RuntimeService) selectPods(isInit bool) error {
 			continue
 		}
 		//不存在该pod，直接加入
-		service.pods = append(service.pods, val)
+		// the poll loop modifies the status of the pods it keeps, the cached one is shared
+		pod := *val
+		service.pods = append(service.pods, &pod)
 	}
 	//更新serviceConfig 并上传
 	//如果没有选取到pod, 需要报错，同时如果已经是错误的不需要在去更新etcd
@@ -168,6 +161,7 @@ func (service *RuntimeService) selectPods(isInit bool) error {
 		}
 	}
 	//更新etcd
+	var err error
 	if isInit {
 		//第一次是一定要更新的
 		err = service.Client.UpdateRuntimeService(service.serviceConfig)
@@ -180,11 +174,11 @@ func (service *RuntimeService) selectPods(isInit bool) error {
 
 //----------------------------------------------------------------------//
 
-func NewRuntimeService(serviceConfig *object.Service, ls *listerwatcher.ListerWatcher, clientConfig client.Config) *RuntimeService {
+func NewRuntimeService(serviceConfig *object.Service, podInformer *informer.Informer[object.Pod], clientConfig client.Config) *RuntimeService {
 	runtimeService := &RuntimeService{}
 	runtimeService.commandChan = make(chan command, 100)
 	runtimeService.serviceConfig = serviceConfig
-	runtimeService.ls = ls
+	runtimeService.podInformer = podInformer
 	runtimeService.Client = client.RESTClient{
 		Base: "http://" + clientConfig.Host,
 	}
@@ -217,7 +211,7 @@ func (service *RuntimeService) syncLoop(commands <-chan command) {
 					//检查pod状态， 如果有错调用select
 					callSelect := false
 					for _, pod := range service.pods {
-						message, err := service.Client.GetRuntimePod(pod.Name)
+						message, err := service.Client.GetRuntimePod(pod.Namespace, pod.Name)
 						if err != nil {
 							service.Err = err
 							fmt.Println("[runtimeService] GetRuntimePod error")
@@ -229,8 +223,9 @@ func (service *RuntimeService) syncLoop(commands <-chan command) {
 							callSelect = true
 							continue
 						}
-						if message.Status.Phase != object.Running {
+						if !message.IsReady() {
 							pod.Status.Phase = message.Status.Phase
+							pod.Status.Conditions = message.Status.Conditions
 							callSelect = true
 						}
 					}
@@ -279,7 +274,7 @@ func (service *RuntimeService) DeleteService() {
 	//关闭隧道
 	close(service.commandChan)
 	//删除etcd中的东西
-	err := service.Client.DeleteRuntimeService(service.serviceConfig.MetaData.Name)
+	err := service.Client.DeleteRuntimeService(service.serviceConfig.MetaData.Namespace, service.serviceConfig.MetaData.Name)
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBaseDelay = 5 * time.Millisecond
+	defaultMaxDelay  = 30 * time.Second
+)
+
+var (
+	depthMetric = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "workqueue_depth",
+		Help: "Number of items waiting in the work queue.",
+	}, []string{"name"})
+	addsMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_adds_total",
+		Help: "Number of items added to the work queue.",
+	}, []string{"name"})
+	retriesMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_retries_total",
+		Help: "Number of items requeued after a failure.",
+	}, []string{"name"})
+	droppedMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "workqueue_dropped_total",
+		Help: "Number of items dropped after too many retries.",
+	}, []string{"name"})
+	workDurationMetric = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "workqueue_work_duration_seconds",
+		Help:    "Time spent processing an item, from Get to Done.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"name"})
+)
+
+// RateLimitingQueue is a work queue of keys shared by the workers of a controller.
+//
+// A key is only queued once however many times it is added before a worker gets it,
+// and a worker never processes a key another worker is processing: a key added while it
+// is processed is queued again when the worker calls Done.
+// A worker which fails adds the key back with AddRateLimited, it is retried after an
+// exponential backoff until maxRetries is reached. Forget resets the backoff after a success.
+type RateLimitingQueue[T comparable] struct {
+	name       string
+	queue      []T
+	dirty      map[T]struct{}  // dirty are the keys waiting to be processed
+	processing map[T]struct{}  // processing are the keys got and not done yet
+	startTimes map[T]time.Time // startTimes are when the processing keys were got
+	failures   map[T]int       // failures counts the retries of every key since it was forgotten
+	cond       *sync.Cond
+	shutdown   bool
+
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxRetries int
+}
+
+// NewRateLimitingQueue returns an empty queue, name labels its metrics.
+// A key which fails more than maxRetries times in a row is dropped, maxRetries < 0 retries forever.
+func NewRateLimitingQueue[T comparable](name string, maxRetries int) *RateLimitingQueue[T] {
+	return &RateLimitingQueue[T]{
+		name:       name,
+		dirty:      make(map[T]struct{}),
+		processing: make(map[T]struct{}),
+		startTimes: make(map[T]time.Time),
+		failures:   make(map[T]int),
+		cond:       sync.NewCond(&sync.Mutex{}),
+		baseDelay:  defaultBaseDelay,
+		maxDelay:   defaultMaxDelay,
+		maxRetries: maxRetries,
+	}
+}
+
+// Add queues item unless it is already waiting
+func (q *RateLimitingQueue[T]) Add(item T) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if q.shutdown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+	addsMetric.WithLabelValues(q.name).Inc()
+	q.dirty[item] = struct{}{}
+	if _, ok := q.processing[item]; ok {
+		// Done queues it
+		return
+	}
+	q.push(item)
+}
+
+// AddAfter adds item once delay has passed
+func (q *RateLimitingQueue[T]) AddAfter(item T, delay time.Duration) {
+	if delay <= 0 {
+		q.Add(item)
+		return
+	}
+	time.AfterFunc(delay, func() {
+		q.Add(item)
+	})
+}
+
+// AddRateLimited adds item back after a failure, with a delay doubling on every failure.
+// It returns false if item has failed too many times and is dropped.
+func (q *RateLimitingQueue[T]) AddRateLimited(item T) bool {
+	q.cond.L.Lock()
+	failures := q.failures[item]
+	if q.maxRetries >= 0 && failures >= q.maxRetries {
+		delete(q.failures, item)
+		q.cond.L.Unlock()
+		droppedMetric.WithLabelValues(q.name).Inc()
+		return false
+	}
+	q.failures[item] = failures + 1
+	q.cond.L.Unlock()
+	retriesMetric.WithLabelValues(q.name).Inc()
+	q.AddAfter(item, q.backoff(failures))
+	return true
+}
+
+// backoff returns baseDelay * 2^failures, up to maxDelay
+func (q *RateLimitingQueue[T]) backoff(failures int) time.Duration {
+	delay := q.baseDelay
+	for i := 0; i < failures; i++ {
+		delay *= 2
+		if delay >= q.maxDelay {
+			return q.maxDelay
+		}
+	}
+	return delay
+}
+
+// Forget resets the backoff of item, call it once item has been processed successfully
+func (q *RateLimitingQueue[T]) Forget(item T) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	delete(q.failures, item)
+}
+
+// NumRequeues returns how many times item has been added back since it was forgotten
+func (q *RateLimitingQueue[T]) NumRequeues(item T) int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.failures[item]
+}
+
+// Get blocks until an item is queued and returns it, the caller must call Done with it once processed.
+// shutdown is true once the queue is shut down and empty.
+func (q *RateLimitingQueue[T]) Get() (item T, shutdown bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for len(q.queue) == 0 && !q.shutdown {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		return item, true
+	}
+	item = q.queue[0]
+	var zero T
+	q.queue[0] = zero
+	q.queue = q.queue[1:]
+	depthMetric.WithLabelValues(q.name).Set(float64(len(q.queue)))
+
+	q.processing[item] = struct{}{}
+	q.startTimes[item] = time.Now()
+	delete(q.dirty, item)
+	return item, false
+}
+
+// Done marks item as processed, it is queued again if it was added while it was processed
+func (q *RateLimitingQueue[T]) Done(item T) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if start, ok := q.startTimes[item]; ok {
+		workDurationMetric.WithLabelValues(q.name).Observe(time.Since(start).Seconds())
+		delete(q.startTimes, item)
+	}
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.push(item)
+	}
+}
+
+// Len returns the number of items waiting
+func (q *RateLimitingQueue[T]) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return len(q.queue)
+}
+
+// ShutDown stops accepting items, Get returns shutdown once the waiting items have been got
+func (q *RateLimitingQueue[T]) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.shutdown = true
+	q.cond.Broadcast()
+}
+
+func (q *RateLimitingQueue[T]) push(item T) {
+	q.queue = append(q.queue, item)
+	depthMetric.WithLabelValues(q.name).Set(float64(len(q.queue)))
+	q.cond.Signal()
+}
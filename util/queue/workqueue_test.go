@@ -0,0 +1,55 @@
+package queue
+
+import (
+	"gotest.tools/v3/assert"
+	"testing"
+	"time"
+)
+
+func TestRateLimitingQueueDedup(t *testing.T) {
+	q := NewRateLimitingQueue[string]("test-dedup", 3)
+	q.Add("aaa")
+	q.Add("bbb")
+	q.Add("aaa")
+	assert.Equal(t, 2, q.Len())
+
+	item, shutdown := q.Get()
+	assert.Equal(t, "aaa", item)
+	assert.Equal(t, false, shutdown)
+	// added while processed, it waits for Done
+	q.Add("aaa")
+	assert.Equal(t, 1, q.Len())
+	q.Done("aaa")
+	assert.Equal(t, 2, q.Len())
+
+	q.ShutDown()
+	item, _ = q.Get()
+	assert.Equal(t, "bbb", item)
+	item, _ = q.Get()
+	assert.Equal(t, "aaa", item)
+	_, shutdown = q.Get()
+	assert.Equal(t, true, shutdown)
+}
+
+func TestRateLimitingQueueRetry(t *testing.T) {
+	q := NewRateLimitingQueue[string]("test-retry", 2)
+	q.baseDelay = time.Millisecond
+	q.maxDelay = 2 * time.Millisecond
+	assert.Equal(t, time.Millisecond, q.backoff(0))
+	assert.Equal(t, 2*time.Millisecond, q.backoff(5))
+
+	assert.Equal(t, true, q.AddRateLimited("aaa"))
+	item, _ := q.Get()
+	assert.Equal(t, "aaa", item)
+	q.Done(item)
+	assert.Equal(t, true, q.AddRateLimited("aaa"))
+	item, _ = q.Get()
+	q.Done(item)
+	assert.Equal(t, 2, q.NumRequeues("aaa"))
+	assert.Equal(t, false, q.AddRateLimited("aaa"))
+	assert.Equal(t, 0, q.NumRequeues("aaa"))
+
+	assert.Equal(t, true, q.AddRateLimited("bbb"))
+	q.Forget("bbb")
+	assert.Equal(t, 0, q.NumRequeues("bbb"))
+}